@@ -1,25 +1,41 @@
 package main
 
 import (
+	imageutil "Q-Solver/pkg/ImageUtil"
+	"Q-Solver/pkg/codeblock"
 	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/export"
+	"Q-Solver/pkg/history"
 	"Q-Solver/pkg/live"
 	"Q-Solver/pkg/llm"
 	"Q-Solver/pkg/logger"
 	"Q-Solver/pkg/platform"
+	"Q-Solver/pkg/prompts"
 	"Q-Solver/pkg/resume"
+	"Q-Solver/pkg/runner"
 	"Q-Solver/pkg/screen"
 	"Q-Solver/pkg/shortcut"
 	"Q-Solver/pkg/solution"
 	"Q-Solver/pkg/state"
 	"Q-Solver/pkg/task"
+	"Q-Solver/pkg/telemetry"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// batchThumbnailSize 多页截图预览缩略图的最大边长
+const batchThumbnailSize = 240
+
 // 这个只作为连接前端和后端的中间层
 type App struct {
 	ctx context.Context
@@ -30,12 +46,19 @@ type App struct {
 	taskManager   *task.TaskCoordinator
 
 	// 业务服务
-	llmService      *llm.Service
-	resumeService   *resume.Service
-	shortcutService *shortcut.Service
-	screenService   *screen.Service
-	solver          *solution.Solver
-	liveManager     *live.LiveSessionManager
+	telemetryService *telemetry.Service
+	llmService       *llm.Service
+	resumeService    *resume.Service
+	shortcutService  *shortcut.Service
+	screenService    *screen.Service
+	solver           *solution.Solver
+	compareLog       *solution.CompareLog
+	batch            *solution.Batch
+	historyStore     *history.Store
+	lastRun          *runner.Result     // 最近一次本地运行结果（用于反馈修正）
+	runCancel        context.CancelFunc // 取消正在进行的本地运行
+	runMu            sync.Mutex
+	liveManager      *live.LiveSessionManager
 }
 
 // NewApp 创建 App 实例
@@ -47,6 +70,7 @@ func NewApp() *App {
 		stateManager:  state.NewStateManager(),
 		taskManager:   task.NewTaskCoordinator(),
 		screenService: screen.NewService(),
+		batch:         solution.NewBatch(),
 	}
 
 	return app
@@ -74,9 +98,17 @@ func (a *App) Startup(ctx context.Context) {
 	// 初始化屏幕服务
 	a.screenService.Startup(ctx)
 
+	// 初始化可观测性（需早于 LLM 服务，保证首个请求即可被追踪）
+	a.telemetryService = telemetry.NewService(a.configManager.Get(), a.configManager)
+
 	// 初始化 LLM 服务
 	a.llmService = llm.NewService(a.configManager.Get(), a.configManager)
 	a.solver = solution.NewSolver(a.llmService.GetProvider())
+	a.updateAuxProviders(a.configManager.Get())
+	a.compareLog = solution.NewCompareLog(a.configManager.GetConfigDir())
+	a.historyStore = history.NewStore(filepath.Join(a.configManager.GetConfigDir(), "history"))
+	a.historyStore.SetSaveImages(a.configManager.Get().HistoryScreenshots)
+	a.solver.SetHistoryStore(a.historyStore)
 
 	// 初始化简历服务
 	a.resumeService = resume.NewService(a.configManager.Get(), a.configManager)
@@ -102,6 +134,12 @@ func (a *App) Startup(ctx context.Context) {
 		a.screenService,
 		a.EmitEvent,
 	)
+	a.liveManager.SetHistoryStore(a.historyStore)
+
+	// 拖入窗口的文件直接发送解题
+	runtime.OnFileDrop(ctx, func(x, y int, paths []string) {
+		a.SolveFiles(paths)
+	})
 
 	// 直接设置为就绪状态
 	a.stateManager.UpdateInitStatus(state.StatusReady)
@@ -112,10 +150,15 @@ func (a *App) onConfigChanged(NewConfig config.Config, oldConfig config.Config)
 	// 更新 solver 的 provider
 	if a.solver != nil {
 		a.solver.SetProvider(a.llmService.GetProvider())
+		a.updateAuxProviders(NewConfig)
+	}
+
+	if a.historyStore != nil {
+		a.historyStore.SetSaveImages(NewConfig.HistoryScreenshots)
 	}
 
 	// 如果关闭了上下文，清空历史
-	if !NewConfig.KeepContext && a.solver != nil {
+	if !NewConfig.KeepContext && oldConfig.KeepContext && a.solver != nil {
 		a.solver.ClearHistory()
 	}
 
@@ -135,6 +178,21 @@ func (a *App) onConfigChanged(NewConfig config.Config, oldConfig config.Config)
 	logger.Println("配置已更新并应用")
 }
 
+// updateAuxProviders 根据配置设置快慢双模型中的快速模型和辅助模型
+func (a *App) updateAuxProviders(cfg config.Config) {
+	if cfg.SpeculativeSolve && cfg.FastModel != "" && cfg.FastModel != cfg.Model {
+		a.solver.SetFastProvider(a.llmService.GetProviderForModel(cfg.FastModel))
+	} else {
+		a.solver.SetFastProvider(nil)
+	}
+
+	if cfg.AssistantModel != "" {
+		a.solver.SetAssistantProvider(a.llmService.GetProviderForModel(cfg.AssistantModel))
+	} else {
+		a.solver.SetAssistantProvider(nil)
+	}
+}
+
 // OnShutdown Wails 关闭回调
 func (a *App) OnShutdown(ctx context.Context) {
 	if a.shortcutService != nil {
@@ -144,6 +202,10 @@ func (a *App) OnShutdown(ctx context.Context) {
 	if err := a.configManager.Save(); err != nil {
 		logger.Printf("保存配置失败: %v", err)
 	}
+	// 刷新未导出的链路与指标
+	if a.telemetryService != nil {
+		a.telemetryService.Shutdown(ctx)
+	}
 }
 
 // ==================== 事件与状态 ====================
@@ -208,8 +270,23 @@ func (a *App) RemoveFocus() {
 
 // ==================== 解题相关 ====================
 
-// TriggerSolve 触发解题（快捷键调用）
+// TriggerSolve 触发解题（快捷键调用），使用当前解题方案
 func (a *App) TriggerSolve() {
+	a.triggerSolve("")
+}
+
+// SolveWithProfile 使用指定解题方案解题（方案快捷键调用），不改变当前方案
+func (a *App) SolveWithProfile(profileID string) {
+	cfg := a.configManager.Get()
+	if _, ok := cfg.FindProfile(profileID); !ok {
+		a.EmitEvent("toast", "解题方案不存在: "+profileID)
+		return
+	}
+	a.triggerSolve(profileID)
+}
+
+// triggerSolve 截图解题，profileID 为空时使用当前解题方案
+func (a *App) triggerSolve(profileID string) {
 	cfg := a.configManager.Get()
 
 	// Live 模式下禁用手动截图
@@ -229,7 +306,7 @@ func (a *App) TriggerSolve() {
 	ctx, taskID := a.taskManager.StartTask("solve")
 
 	go func() {
-		success := a.solveInternal(ctx)
+		success := a.solveInternal(ctx, profileID)
 
 		if success {
 			a.taskManager.CompleteTask(taskID)
@@ -237,52 +314,701 @@ func (a *App) TriggerSolve() {
 	}()
 }
 
-// solveInternal 内部解题逻辑
-func (a *App) solveInternal(ctx context.Context) bool {
+// SolveWithVoting 截图后并行采样多次并按最终答案多数表决（快捷键调用），适用于选择题与数值题
+func (a *App) SolveWithVoting() {
 	cfg := a.configManager.Get()
 
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持手动截图")
+		return
+	}
 	if cfg.APIKey == "" {
 		a.EmitEvent("require-login")
+		return
+	}
+
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("solve_vote")
+
+	go func() {
+		req, ok := a.buildSolveRequest(a.solveConfig(""))
+		if !ok {
+			return
+		}
+		if _, ok := a.solver.Vote(ctx, req, solution.Callbacks{EmitEvent: a.EmitEvent}); ok {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// solveInternal 内部解题逻辑
+func (a *App) solveInternal(ctx context.Context, profileID string) bool {
+	req, ok := a.buildSolveRequest(a.solveConfig(profileID))
+	if !ok {
 		return false
 	}
 
-	// 读取简历
-	resumeBase64, err := a.resumeService.GetResumeBase64()
+	cb := solution.Callbacks{
+		EmitEvent: a.EmitEvent,
+	}
+
+	// 方案快捷键指定了方案时不参与自动路由
+	if profileID == "" {
+		a.routeRequest(ctx, &req, cb)
+	}
+
+	return a.solver.Solve(ctx, req, cb)
+}
+
+// routeRequest 开启自动路由时识别截图的题型，并切换到对应解题方案的提示词与模型
+// 截图已按当前方案的设置截取，不再重新截图；识别失败时沿用当前方案
+func (a *App) routeRequest(ctx context.Context, req *solution.Request, cb solution.Callbacks) {
+	cfg := a.configManager.Get()
+	if !cfg.AutoRoute || req.ScreenshotBase64 == "" {
+		return
+	}
+
+	routing, err := a.solver.Route(ctx, cfg, req.ScreenshotBase64, cb)
 	if err != nil {
-		logger.Printf("读取简历失败: %v\n", err)
+		if ctx.Err() == nil {
+			logger.Printf("[路由] 识别题型失败，沿用当前解题方案: %v", err)
+		}
+		return
+	}
+	req.Routing = routing
+	if routing.ProfileID == "" || routing.ProfileID == cfg.ActiveProfile {
+		return
+	}
+
+	routed := cfg.WithProfile(routing.ProfileID)
+	req.Config = routed
+	req.Provider = a.llmService.GetProviderForConfig(routed)
+	req.ResumeBase64 = a.readResume(routed)
+}
+
+// solveConfig 返回应用解题方案后的配置，profileID 为空时使用当前解题方案
+func (a *App) solveConfig(profileID string) config.Config {
+	cfg := a.configManager.Get()
+	if profileID == "" {
+		profileID = cfg.ActiveProfile
+	}
+	return cfg.WithProfile(profileID)
+}
+
+// SolveFiles 将拖入或选择的文件（图片、PDF、Markdown、源文件）发送给模型，使用当前解题方案
+func (a *App) SolveFiles(paths []string) {
+	cfg := a.solveConfig("")
+
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持文件解题")
+		return
+	}
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return
+	}
+
+	attachments, err := solution.LoadAttachments(paths, cfg)
+	if err != nil {
+		logger.Printf("读取文件失败: %v", err)
+		a.EmitEvent("toast", err.Error())
+		return
+	}
+
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("solve_files")
+
+	go func() {
+		if a.solveFilesInternal(ctx, cfg, attachments) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// ChooseFilesToSolve 弹出文件选择对话框，选择后发送解题
+func (a *App) ChooseFilesToSolve() (bool, error) {
+	paths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择要发送的文件",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "图片、PDF、文档与源文件", Pattern: "*.png;*.jpg;*.jpeg;*.gif;*.pdf;*.md;*.markdown;*.txt;*.go;*.py;*.js;*.ts;*.java;*.c;*.cpp;*.h;*.cs;*.rs;*.kt;*.swift;*.sql;*.json;*.yaml;*.yml"},
+			{DisplayName: "所有文件", Pattern: "*.*"},
+		},
+	})
+	if err != nil {
+		return false, err
 	}
+	if len(paths) == 0 {
+		return false, nil // 用户取消
+	}
+	a.SolveFiles(paths)
+	return true, nil
+}
+
+// solveFilesInternal 文件解题逻辑，与 solveInternal 共用 Solve 流程与流式事件
+func (a *App) solveFilesInternal(ctx context.Context, cfg config.Config, attachments []solution.Attachment) bool {
+	parts := make([]llm.ContentPart, 0, len(attachments))
+	for _, att := range attachments {
+		parts = append(parts, att.Part)
+	}
+
+	// 发送用户输入到前端显示
+	a.EmitEvent("user-files", attachments)
+
+	req := solution.Request{
+		Config:       cfg,
+		Provider:     a.llmService.GetProviderForConfig(cfg),
+		Attachments:  parts,
+		ResumeBase64: a.readResume(cfg),
+	}
+	return a.solver.Solve(ctx, req, solution.Callbacks{EmitEvent: a.EmitEvent})
+}
+
+// buildSolveRequest 按配置截图并读取简历，构建解题请求
+func (a *App) buildSolveRequest(cfg config.Config) (solution.Request, bool) {
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return solution.Request{}, false
+	}
+
+	screenshot, ok := a.captureScreenshot(cfg)
+	if !ok {
+		return solution.Request{}, false
+	}
+
+	// 发送用户截图到前端（用于导出图片显示用户输入）
+	a.EmitEvent("user-message", screenshot)
+
+	return solution.Request{
+		Config:           cfg,
+		Provider:         a.llmService.GetProviderForConfig(cfg),
+		ScreenshotBase64: screenshot,
+		ResumeBase64:     a.readResume(cfg),
+	}, true
+}
 
-	// 获取截图
-	previewResult, err := a.GetScreenshotPreview(
+// captureScreenshot 按配置截图并编码
+func (a *App) captureScreenshot(cfg config.Config) (string, bool) {
+	previewResult, ok := a.capturePreview(cfg)
+	return previewResult.Base64, ok
+}
+
+// capturePreview 按配置截图，返回包含原始字节的结果
+func (a *App) capturePreview(cfg config.Config) (screen.PreviewResult, bool) {
+	previewResult, err := a.screenService.CapturePreview(
 		cfg.CompressionQuality,
 		cfg.Sharpening,
 		cfg.Grayscale,
 		cfg.NoCompression,
 		cfg.ScreenshotMode,
+		cfg.ScreenshotRegion.Rect(),
 	)
 	if err != nil {
 		logger.Printf("图片编码失败: %v\n", err)
-		return false
+		return screen.PreviewResult{}, false
 	}
+	return previewResult, true
+}
 
-	// 发送用户截图到前端（用于导出图片显示用户输入）
-	a.EmitEvent("user-message", previewResult.Base64)
+// readResume 读取简历 Base64，失败或解题方案不附带简历时返回空
+func (a *App) readResume(cfg config.Config) string {
+	if cfg.ResumePath == "" {
+		return ""
+	}
+	resumeBase64, err := a.resumeService.GetResumeBase64()
+	if err != nil {
+		logger.Printf("读取简历失败: %v\n", err)
+	}
+	return resumeBase64
+}
 
-	req := solution.Request{
-		Config:           cfg,
-		ScreenshotBase64: previewResult.Base64,
-		ResumeBase64:     resumeBase64,
+// Ask 在当前对话中追问，withScreenshot 为 true 时附带一张新截图
+func (a *App) Ask(text string, withScreenshot bool) {
+	cfg := a.solveConfig("")
+
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return
+	}
+	if strings.TrimSpace(text) == "" && !withScreenshot {
+		return
+	}
+	if withScreenshot && cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持手动截图")
+		return
 	}
 
-	cb := solution.Callbacks{
-		EmitEvent: a.EmitEvent,
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("ask")
+
+	go func() {
+		req := solution.Request{
+			Config:       cfg,
+			Provider:     a.llmService.GetProviderForConfig(cfg),
+			Text:         text,
+			ResumeBase64: a.readResume(cfg),
+		}
+		if withScreenshot {
+			screenshot, ok := a.captureScreenshot(cfg)
+			if !ok {
+				return
+			}
+			req.ScreenshotBase64 = screenshot
+		}
+
+		a.EmitEvent("user-ask", map[string]string{
+			"text":       text,
+			"screenshot": req.ScreenshotBase64,
+		})
+
+		if a.solver.Ask(ctx, req, solution.Callbacks{EmitEvent: a.EmitEvent}) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// ContinueAnswer 继续输出当前对话中被中断的回答
+func (a *App) ContinueAnswer() {
+	cfg := a.solveConfig("")
+
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return
+	}
+	if !a.solver.HasInterrupted() {
+		a.EmitEvent("toast", solution.ErrNothingToContinue.Error())
+		return
 	}
 
-	return a.solver.Solve(ctx, req, cb)
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("continue")
+
+	go func() {
+		req := solution.Request{
+			Config:   cfg,
+			Provider: a.llmService.GetProviderForConfig(cfg),
+		}
+		if a.solver.Continue(ctx, req, solution.Callbacks{EmitEvent: a.EmitEvent}) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// ==================== 改进动作 ====================
+
+// GetRefineActions 获取所有改进动作
+func (a *App) GetRefineActions() []config.RefineAction {
+	cfg := a.configManager.Get()
+	return cfg.Refines()
+}
+
+// Refine 对当前对话的上一个回答执行改进动作（快捷键或按钮调用），不截图
+func (a *App) Refine(actionID string) {
+	cfg := a.solveConfig("")
+
+	action, ok := cfg.FindRefineAction(actionID)
+	if !ok {
+		a.EmitEvent("toast", "改进动作不存在: "+actionID)
+		return
+	}
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持改进回答")
+		return
+	}
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return
+	}
+	if a.solver.LastAnswer() == "" {
+		a.EmitEvent("toast", solution.ErrNothingToRefine.Error())
+		return
+	}
+
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("refine")
+
+	go func() {
+		req := solution.Request{
+			Config:   cfg,
+			Provider: a.llmService.GetProviderForConfig(cfg),
+		}
+
+		a.EmitEvent("user-ask", map[string]string{
+			"text":       solution.RefinePrompt(action, a.solver.LastAnswer(), cfg),
+			"screenshot": "",
+			"refine":     action.Name,
+		})
+
+		if a.solver.Refine(ctx, req, action, solution.Callbacks{EmitEvent: a.EmitEvent}) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// ==================== 解题方案 ====================
+
+// GetProfiles 获取所有解题方案
+func (a *App) GetProfiles() []config.SolveProfile {
+	return a.configManager.Get().Profiles
+}
+
+// SetActiveProfile 切换当前解题方案，传空字符串恢复使用主配置
+func (a *App) SetActiveProfile(profileID string) error {
+	cfg := a.configManager.Get()
+	if profileID != "" {
+		if _, ok := cfg.FindProfile(profileID); !ok {
+			return fmt.Errorf("解题方案不存在: %s", profileID)
+		}
+	}
+	cfg.ActiveProfile = profileID
+	jsonData, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return a.configManager.UpdateFromJSON(string(jsonData))
+}
+
+// ==================== 语言偏好 ====================
+
+// SetAnswerLanguage 设置回答语言（"zh"、"en"，空字符串表示跟随提示词），下一次请求生效
+func (a *App) SetAnswerLanguage(lang string) error {
+	if lang != "" && lang != prompts.LanguageChinese && lang != prompts.LanguageEnglish {
+		return fmt.Errorf("不支持的回答语言: %s", lang)
+	}
+	cfg := a.configManager.Get()
+	cfg.AnswerLanguage = lang
+	jsonData, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := a.configManager.UpdateFromJSON(string(jsonData)); err != nil {
+		return err
+	}
+	a.EmitEvent("answer-language-changed", lang)
+	return nil
+}
+
+// ToggleAnswerLanguage 在中文与英文之间切换回答语言（快捷键调用）
+// 开启 ReaskOnLanguageSwitch 时用新语言重新回答当前对话的上一题
+func (a *App) ToggleAnswerLanguage() {
+	lang := prompts.LanguageEnglish
+	if a.configManager.Get().AnswerLanguage == prompts.LanguageEnglish {
+		lang = prompts.LanguageChinese
+	}
+	if err := a.SetAnswerLanguage(lang); err != nil {
+		logger.Printf("切换回答语言失败: %v", err)
+		a.EmitEvent("toast", "切换回答语言失败")
+		return
+	}
+	logger.Printf("回答语言已切换为 %s", prompts.LanguageName(lang))
+	a.EmitEvent("toast", "回答语言："+prompts.LanguageName(lang))
+
+	cfg := a.configManager.Get()
+	if cfg.ReaskOnLanguageSwitch && !cfg.UseLiveApi && a.solver.LastAnswer() != "" {
+		a.Ask(prompts.ReaskPrompt(lang), false)
+	}
+}
+
+// ==================== 多页截图 ====================
+
+// AddBatchPage 截取一页加入待解题队列（快捷键调用）
+func (a *App) AddBatchPage() {
+	cfg := a.solveConfig("")
+
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持手动截图")
+		return
+	}
+
+	previewResult, ok := a.capturePreview(cfg)
+	if !ok {
+		a.EmitEvent("toast", "截图失败")
+		return
+	}
+
+	page := solution.BatchPage{
+		Base64: previewResult.Base64,
+		Size:   previewResult.Size,
+	}
+	if thumb, err := imageutil.Thumbnail(previewResult.ImgBytes, batchThumbnailSize); err == nil {
+		page.Thumbnail = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(thumb)
+	} else {
+		logger.Printf("生成缩略图失败: %v", err)
+	}
+
+	pages, err := a.batch.Add(page)
+	if err != nil {
+		a.EmitEvent("toast", err.Error())
+		return
+	}
+	logger.Printf("已添加第 %d 页截图", len(pages))
+	a.EmitEvent("batch-updated", pages)
+}
+
+// GetBatchPages 获取待解题截图的预览列表
+func (a *App) GetBatchPages() []solution.BatchPage {
+	return a.batch.Pages()
+}
+
+// RemoveBatchPage 从待解题队列移除一页截图
+func (a *App) RemoveBatchPage(id string) bool {
+	removed := a.batch.Remove(id)
+	if removed {
+		a.EmitEvent("batch-updated", a.batch.Pages())
+	}
+	return removed
+}
+
+// ClearBatch 清空待解题队列
+func (a *App) ClearBatch() {
+	a.batch.Clear()
+	a.EmitEvent("batch-updated", []solution.BatchPage{})
+}
+
+// SolveBatch 将累积的截图按顺序合并为一条消息解题（快捷键调用）
+func (a *App) SolveBatch() {
+	cfg := a.solveConfig("")
+
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持手动截图")
+		return
+	}
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return
+	}
+
+	pages, err := a.batch.Take()
+	if err != nil {
+		a.EmitEvent("toast", err.Error())
+		return
+	}
+	a.EmitEvent("batch-updated", []solution.BatchPage{})
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("solve_batch")
+
+	go func() {
+		screenshots := make([]string, 0, len(pages))
+		for _, page := range pages {
+			screenshots = append(screenshots, page.Base64)
+			a.EmitEvent("user-message", page.Base64)
+		}
+
+		req := solution.Request{
+			Config:       cfg,
+			Provider:     a.llmService.GetProviderForConfig(cfg),
+			Screenshots:  screenshots,
+			ResumeBase64: a.readResume(cfg),
+		}
+		if a.solver.Solve(ctx, req, solution.Callbacks{EmitEvent: a.EmitEvent}) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// SolveClipboard 读取剪贴板中的图片或文字解题（快捷键调用）
+// 图片按截图设置压缩后作为截图发送，文字作为文本内容发送
+func (a *App) SolveClipboard() {
+	cfg := a.solveConfig("")
+
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持剪贴板解题")
+		return
+	}
+	if cfg.APIKey == "" {
+		a.EmitEvent("require-login")
+		return
+	}
+
+	img, err := platform.ReadClipboardImage()
+	if err != nil {
+		logger.Printf("读取剪贴板图片失败: %v", err)
+	}
+	var text string
+	if img == nil {
+		if text, err = runtime.ClipboardGetText(a.ctx); err != nil {
+			logger.Printf("读取剪贴板文字失败: %v", err)
+		}
+	}
+	if img == nil && strings.TrimSpace(text) == "" {
+		a.EmitEvent("toast", "剪贴板中没有图片或文字")
+		return
+	}
+
+	a.EmitEvent("start-solving")
+
+	ctx, taskID := a.taskManager.StartTask("solve_clipboard")
+
+	go func() {
+		req := solution.Request{
+			Config:       cfg,
+			Provider:     a.llmService.GetProviderForConfig(cfg),
+			ResumeBase64: a.readResume(cfg),
+		}
+		if img != nil {
+			_, dataURL, err := imageutil.Encode(img, cfg.CompressionQuality, cfg.Sharpening, cfg.Grayscale, cfg.NoCompression)
+			if err != nil {
+				logger.Printf("剪贴板图片编码失败: %v", err)
+				a.EmitEvent("solution-error", err.Error())
+				return
+			}
+			req.ScreenshotBase64 = dataURL
+			a.EmitEvent("user-message", dataURL)
+		} else {
+			req.Text = text
+			a.EmitEvent("user-ask", map[string]string{
+				"text":       text,
+				"screenshot": "",
+			})
+		}
+
+		if a.solver.Solve(ctx, req, solution.Callbacks{EmitEvent: a.EmitEvent}) {
+			a.taskManager.CompleteTask(taskID)
+		}
+	}()
+}
+
+// TriggerCompare 触发多模型对比（同一截图并行发送给配置的多个模型）
+func (a *App) TriggerCompare() {
+	cfg := a.solveConfig("")
+
+	if cfg.UseLiveApi {
+		a.EmitEvent("toast", "当前模式不支持手动截图")
+		return
+	}
+	if len(cfg.CompareTargets) == 0 {
+		a.EmitEvent("toast", "请先在设置中添加对比模型")
+		return
+	}
+
+	ctx, taskID := a.taskManager.StartTask("compare")
+
+	go func() {
+		defer a.taskManager.CompleteTask(taskID)
+
+		req, ok := a.buildSolveRequest(cfg)
+		if !ok {
+			return
+		}
+
+		targets := make([]solution.CompareTarget, 0, len(cfg.CompareTargets))
+		for _, t := range cfg.CompareTargets {
+			targets = append(targets, solution.CompareTarget{
+				CompareTarget: t,
+				LLM:           a.llmService.GetProviderForTarget(t),
+			})
+		}
+
+		run := a.solver.Compare(ctx, req, targets, solution.Callbacks{EmitEvent: a.EmitEvent})
+		// 被新任务中断的对比不记录
+		if ctx.Err() != nil {
+			return
+		}
+		if err := a.compareLog.Append(run); err != nil {
+			logger.Printf("保存对比记录失败: %v", err)
+		}
+	}()
+}
+
+// GetCompareHistory 获取最近的多模型对比记录
+func (a *App) GetCompareHistory(limit int) ([]solution.CompareRun, error) {
+	return a.compareLog.List(limit)
+}
+
+// ==================== 解题记录 ====================
+
+// ListHistory 列出解题记录（最近的在前）
+func (a *App) ListHistory() ([]history.Summary, error) {
+	return a.historyStore.List()
+}
+
+// SearchHistory 全文搜索解题记录
+func (a *App) SearchHistory(query string) ([]history.Summary, error) {
+	return a.historyStore.Search(query)
+}
+
+// GetHistoryConversation 打开一条解题记录（含截图与完整回答）
+func (a *App) GetHistoryConversation(id string) (*history.Conversation, error) {
+	return a.historyStore.Get(id)
+}
+
+// DeleteHistoryConversation 删除一条解题记录
+func (a *App) DeleteHistoryConversation(id string) error {
+	if err := a.historyStore.Delete(id); err != nil {
+		return err
+	}
+	a.solver.DetachConversation(id)
+	return nil
+}
+
+// ContinueConversation 载入解题记录作为当前对话，后续解题在其基础上继续
+func (a *App) ContinueConversation(id string) (*history.Conversation, error) {
+	conv, err := a.historyStore.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	a.solver.LoadConversation(conv)
+	a.emitConversations()
+	logger.Printf("已载入历史对话: %s (%d 轮)", conv.ID, len(conv.Turns))
+	return conv, nil
+}
+
+// ==================== 多对话相关 ====================
+
+// ListConversations 列出所有打开的对话
+func (a *App) ListConversations() []solution.ConversationInfo {
+	return a.solver.ListConversations()
+}
+
+// CreateConversation 新建空白对话并切换到该对话
+func (a *App) CreateConversation() solution.ConversationInfo {
+	info := a.solver.CreateConversation()
+	a.emitConversations()
+	return info
+}
+
+// SwitchConversation 切换当前对话，后续解题与追问在该对话中进行
+func (a *App) SwitchConversation(id string) (solution.ConversationInfo, error) {
+	info, err := a.solver.SwitchConversation(id)
+	if err != nil {
+		return info, err
+	}
+	a.emitConversations()
+	return info, nil
+}
+
+// ForkConversation 从对话复制出新分支并切换到新分支，id 为空时复制当前对话
+func (a *App) ForkConversation(id string) (solution.ConversationInfo, error) {
+	info, err := a.solver.ForkConversation(id)
+	if err != nil {
+		return info, err
+	}
+	a.emitConversations()
+	return info, nil
+}
+
+// CloseConversation 关闭对话，解题记录仍保留在历史中
+func (a *App) CloseConversation(id string) error {
+	if err := a.solver.CloseConversation(id); err != nil {
+		return err
+	}
+	a.emitConversations()
+	return nil
+}
+
+// emitConversations 通知前端对话列表变化
+func (a *App) emitConversations() {
+	a.EmitEvent("conversations-updated", a.solver.ListConversations())
 }
 
 // CancelRunningTask 取消当前运行的任务
 func (a *App) CancelRunningTask() bool {
+	a.solver.CancelReview()
 	return a.taskManager.CancelCurrentTask()
 }
 
@@ -315,6 +1041,125 @@ func (a *App) CopyCode() {
 	a.EmitEvent("copy-code")
 }
 
+// CopyCodeBlock 复制最近一次回答中的第 index 个代码块到剪贴板
+func (a *App) CopyCodeBlock(index int) error {
+	block, ok := a.solver.CodeBlock(index)
+	if !ok {
+		return fmt.Errorf("代码块 %d 不存在", index)
+	}
+	return runtime.ClipboardSetText(a.ctx, block.Code)
+}
+
+// SaveCodeBlock 保存最近一次回答中的第 index 个代码块到文件（弹出文件选择对话框）
+func (a *App) SaveCodeBlock(index int) (bool, error) {
+	block, ok := a.solver.CodeBlock(index)
+	if !ok {
+		return false, fmt.Errorf("代码块 %d 不存在", index)
+	}
+
+	ext := codeblock.Extension(block.Language)
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "保存代码",
+		DefaultFilename: "solution" + ext,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "代码文件", Pattern: "*" + ext},
+			{DisplayName: "所有文件", Pattern: "*.*"},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if filename == "" {
+		return false, nil // 用户取消
+	}
+
+	if err := os.WriteFile(filename, []byte(block.Code+"\n"), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ==================== 运行代码 ====================
+
+// GetRunnerLanguages 返回可本地运行的语言及其工具链是否已安装
+func (a *App) GetRunnerLanguages() map[string]bool {
+	return runner.AvailableLanguages()
+}
+
+// GetAnswerSamples 从最近一次回答中解析样例输入输出
+func (a *App) GetAnswerSamples() []runner.TestCase {
+	return runner.ParseSamples(a.solver.LastAnswer())
+}
+
+// RunCodeBlock 在本地运行第 index 个代码块，tests 为空时使用回答中解析出的样例
+// 代码没有沙箱隔离，以当前用户权限运行；开始新的运行会取消上一次运行
+func (a *App) RunCodeBlock(index int, tests []runner.TestCase) (runner.Result, error) {
+	block, ok := a.solver.CodeBlock(index)
+	if !ok {
+		return runner.Result{}, fmt.Errorf("代码块 %d 不存在", index)
+	}
+	if len(tests) == 0 {
+		tests = runner.ParseSamples(a.solver.LastAnswer())
+	}
+	if len(tests) == 0 {
+		return runner.Result{}, errors.New("回答中没有找到样例，请手动输入")
+	}
+
+	cfg := a.configManager.Get()
+	limits := runner.Limits{
+		Timeout:  time.Duration(cfg.RunnerTimeout) * time.Second,
+		MemoryMB: cfg.RunnerMemoryMB,
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.runMu.Lock()
+	if a.runCancel != nil {
+		a.runCancel()
+	}
+	a.runCancel = cancel
+	a.runMu.Unlock()
+	defer cancel()
+
+	a.EmitEvent("code-run-start", index)
+	result, err := runner.Run(ctx, block.Language, block.Code, tests, limits)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return runner.Result{}, err
+	}
+
+	a.runMu.Lock()
+	a.lastRun = &result
+	a.runMu.Unlock()
+
+	a.EmitEvent("code-run-result", result)
+	return result, nil
+}
+
+// CancelCodeRun 取消正在进行的本地运行
+func (a *App) CancelCodeRun() {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+	if a.runCancel != nil {
+		a.runCancel()
+		a.runCancel = nil
+	}
+}
+
+// FixWithRunResult 将最近一次未通过的运行结果反馈给模型，请其修正代码
+func (a *App) FixWithRunResult() error {
+	a.runMu.Lock()
+	lastRun := a.lastRun
+	a.runMu.Unlock()
+
+	if lastRun == nil || lastRun.Passed {
+		return errors.New("没有未通过的运行结果")
+	}
+	a.Ask(runner.FeedbackPrompt(*lastRun), false)
+	return nil
+}
+
 // ==================== 简历相关 ====================
 
 // SelectResume 选择简历文件
@@ -353,18 +1198,53 @@ func (a *App) GetResumePDF() (string, error) {
 
 // ParseResume 解析简历为 Markdown
 func (a *App) ParseResume() (string, error) {
-	return a.resumeService.ParseResume(a.ctx, a.llmService.GetProvider())
+	return a.resumeService.ParseResume(a.ctx, a.llmService.GetProvider(), a.configManager.Get().AnswerLanguage)
 }
 
 // ==================== 截图相关 ====================
 
-// GetScreenshotPreview 获取截图预览
+// GetScreenshotPreview 获取截图预览，区域模式使用当前解题方案的截图区域
 func (a *App) GetScreenshotPreview(quality int, sharpen float64, grayscale bool, noCompression bool, screenshotMode string) (screen.PreviewResult, error) {
+	cfg := a.solveConfig("")
 	mode := screenshotMode
 	if mode == "" {
-		mode = a.configManager.Get().ScreenshotMode
+		mode = cfg.ScreenshotMode
+	}
+	return a.screenService.CapturePreview(quality, sharpen, grayscale, noCompression, mode, cfg.ScreenshotRegion.Rect())
+}
+
+// GetRegionSelection 截取整个桌面，供前端框选截图区域
+func (a *App) GetRegionSelection() (screen.RegionSelection, error) {
+	return a.screenService.CaptureDesktop()
+}
+
+// SetCaptureRegion 保存截图区域（框选或手动输入的屏幕坐标）并切换为区域截图模式
+// profileID 为空时设置主配置，否则只设置该解题方案
+func (a *App) SetCaptureRegion(profileID string, region config.CaptureRegion) error {
+	cfg := a.configManager.Get()
+	if err := cfg.SetCaptureRegion(profileID, region); err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := a.configManager.UpdateFromJSON(string(jsonData)); err != nil {
+		return err
+	}
+	logger.Printf("截图区域已设置: (%d, %d) %dx%d", region.X, region.Y, region.Width, region.Height)
+	return nil
+}
+
+// UseWindowAsCaptureRegion 将本窗口当前覆盖的区域保存为截图区域
+// 用户先把窗口移动并缩放到题目所在位置，再调用此方法完成框选
+func (a *App) UseWindowAsCaptureRegion(profileID string) (config.CaptureRegion, error) {
+	bounds, err := a.screenService.WindowBounds()
+	if err != nil {
+		return config.CaptureRegion{}, err
 	}
-	return a.screenService.CapturePreview(quality, sharpen, grayscale, noCompression, mode)
+	region := config.CaptureRegion{X: bounds.Min.X, Y: bounds.Min.Y, Width: bounds.Dx(), Height: bounds.Dy()}
+	return region, a.SetCaptureRegion(profileID, region)
 }
 
 // CheckScreenCapturePermission 检查截图权限 (macOS)
@@ -423,6 +1303,11 @@ func (a *App) TestConnection(apiKey, baseURL, model string) string {
 	return a.llmService.TestConnection(ctx, apiKey, baseURL, model)
 }
 
+// ClearResponseCache 清空响应缓存
+func (a *App) ClearResponseCache() error {
+	return a.llmService.ClearCache()
+}
+
 // GetModels 获取模型列表
 func (a *App) GetModels(apiKey string, baseURL string) ([]string, error) {
 	ctx := a.ctx
@@ -473,6 +1358,97 @@ func (a *App) SaveImageToFile(base64Data string) (bool, error) {
 	return true, nil
 }
 
+// ExportConversation 导出一条解题记录或 Live 会话记录（弹出保存对话框）
+// id 为空时导出最近一次 Live 会话
+func (a *App) ExportConversation(id string, opts export.Options) (bool, error) {
+	var conv *history.Conversation
+	if id == "" {
+		conv = a.liveManager.Transcript()
+		if conv == nil {
+			return false, errors.New("没有可导出的 Live 会话")
+		}
+	} else {
+		var err error
+		if conv, err = a.historyStore.Get(id); err != nil {
+			return false, err
+		}
+	}
+
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出对话",
+		DefaultFilename: export.FileName(conv, opts.Format),
+		Filters:         exportFilters(opts.Format),
+	})
+	if err != nil {
+		return false, err
+	}
+	if filename == "" {
+		return false, nil // 用户取消
+	}
+
+	if err := export.WriteFile(conv, filename, opts); err != nil {
+		return false, err
+	}
+	logger.Printf("[导出] 已导出对话 %s 到 %s", conv.ID, filename)
+	return true, nil
+}
+
+// ExportConversationsInRange 批量导出日期范围内（含首尾两天，格式 2006-01-02，为空表示不限）的全部记录
+// 弹出目录选择对话框，返回导出的数量
+func (a *App) ExportConversationsInRange(from, to string, opts export.Options) (int, error) {
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.ParseInLocation("2006-01-02", from, time.Local); err != nil {
+			return 0, fmt.Errorf("起始日期格式错误: %w", err)
+		}
+	}
+	if to != "" {
+		if end, err = time.ParseInLocation("2006-01-02", to, time.Local); err != nil {
+			return 0, fmt.Errorf("结束日期格式错误: %w", err)
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+
+	convs, err := a.historyStore.Range(start, end)
+	if err != nil {
+		return 0, err
+	}
+	if len(convs) == 0 {
+		return 0, errors.New("所选日期范围内没有记录")
+	}
+
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "选择导出目录",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if dir == "" {
+		return 0, nil // 用户取消
+	}
+
+	count, err := export.WriteDir(convs, dir, opts)
+	if err != nil {
+		return count, err
+	}
+	logger.Printf("[导出] 已批量导出 %d/%d 条记录到 %s", count, len(convs), dir)
+	return count, nil
+}
+
+// exportFilters 保存对话框的文件类型过滤
+func exportFilters(format string) []runtime.FileFilter {
+	switch format {
+	case export.FormatHTML:
+		return []runtime.FileFilter{{DisplayName: "HTML 文件", Pattern: "*.html"}}
+	case export.FormatJSON:
+		return []runtime.FileFilter{{DisplayName: "JSON 文件", Pattern: "*.json"}}
+	default:
+		return []runtime.FileFilter{{DisplayName: "Markdown 文件", Pattern: "*.md"}}
+	}
+}
+
 // ==================== Live API ====================
 
 // StartLiveSession 启动 Live API 会话
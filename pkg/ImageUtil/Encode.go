@@ -0,0 +1,28 @@
+package imageutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// Encode 按截图设置处理图片，返回编码后的字节流和 data URL
+// noCompression 为 true 时保留原图编码为 PNG，否则走 CompressForOCR 压缩为 JPEG
+func Encode(img image.Image, quality int, sharpen float64, grayscale bool, noCompression bool) ([]byte, string, error) {
+	if noCompression {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("图片编码失败: %v", err)
+		}
+		data := buf.Bytes()
+		return data, "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	data, err := CompressForOCR(img, quality, sharpen, grayscale)
+	if err != nil {
+		return nil, "", fmt.Errorf("图片处理失败: %v", err)
+	}
+	return data, "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,26 @@
+package imageutil
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/disintegration/imaging"
+)
+
+// Thumbnail 将图片字节流缩放为不超过 maxSize 的 JPEG 缩略图（用于前端预览）
+func Thumbnail(data []byte, maxSize int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	thumb := imaging.Fit(img, maxSize, maxSize, imaging.Box)
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, thumb, &jpeg.Options{Quality: 70}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
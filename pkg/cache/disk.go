@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 缓存目录中的文件
+const (
+	entryExt    = ".cache" // 缓存条目扩展名
+	tmpPrefix   = "tmp-"   // 写入中的临时文件前缀
+	staleTmpAge = 10 * time.Minute
+)
+
+// DiskCache 基于文件的键值缓存，支持过期时间和总大小限制
+// 每个条目一个文件，以写入时间作为过期和淘汰依据
+type DiskCache struct {
+	dir      string
+	mu       sync.Mutex
+	ttl      time.Duration // 0 表示不过期
+	maxBytes int64         // 0 表示不限制
+}
+
+// NewDiskCache 创建磁盘缓存，目录在第一次写入时创建
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// SetLimits 更新过期时间和容量限制
+func (c *DiskCache) SetLimits(ttl time.Duration, maxBytes int64) {
+	c.mu.Lock()
+	c.ttl = ttl
+	c.maxBytes = maxBytes
+	c.mu.Unlock()
+}
+
+// Get 读取缓存，不存在或已过期时返回 false
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.expired(info, time.Now()) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put 写入缓存，并按限制清理旧条目
+func (c *DiskCache) Put(key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免读到写了一半的条目
+	tmp, err := os.CreateTemp(c.dir, tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.prune()
+	return nil
+}
+
+// Clear 删除全部缓存条目
+func (c *DiskCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), entryExt) {
+			_ = os.Remove(filepath.Join(c.dir, e.Name()))
+		}
+	}
+	return nil
+}
+
+// path 返回条目文件路径
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key+entryExt)
+}
+
+// expired 检查条目是否过期
+func (c *DiskCache) expired(info os.FileInfo, now time.Time) bool {
+	return c.ttl > 0 && now.Sub(info.ModTime()) > c.ttl
+}
+
+// prune 删除过期条目与写入中断残留的临时文件，并在超出容量时从最旧的开始淘汰（调用方需持有锁）
+func (c *DiskCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type item struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	now := time.Now()
+	var items []item
+	var total int64
+	for _, e := range entries {
+		name := e.Name()
+		isTmp := strings.HasPrefix(name, tmpPrefix)
+		if !isTmp && !strings.HasSuffix(name, entryExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, name)
+		if isTmp {
+			// 写入都在锁内完成，较旧的临时文件只可能是进程退出时遗留的
+			if now.Sub(info.ModTime()) > staleTmpAge {
+				_ = os.Remove(path)
+			}
+			continue
+		}
+		if c.expired(info, now) {
+			_ = os.Remove(path)
+			continue
+		}
+		items = append(items, item{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	if c.maxBytes <= 0 || total <= c.maxBytes {
+		return
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].modTime.Before(items[j].modTime)
+	})
+	for _, it := range items {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(it.path); err == nil {
+			total -= it.size
+		}
+	}
+}
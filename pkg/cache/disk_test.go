@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// putAged 写入条目并将修改时间设为 age 之前
+func putAged(t *testing.T, c *DiskCache, key string, size int, age time.Duration) {
+	t.Helper()
+	if err := c.Put(key, make([]byte, size)); err != nil {
+		t.Fatalf("Put(%s): %v", key, err)
+	}
+	old := time.Now().Add(-age)
+	if err := os.Chtimes(c.path(key), old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskCachePrune(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		maxBytes int64
+		want     []string // prune 后保留的条目
+	}{
+		{"no limits", 0, 0, []string{"old", "mid", "new"}},
+		{"ttl removes expired", 90 * time.Minute, 0, []string{"mid", "new"}},
+		{"size evicts oldest first", 0, 250, []string{"mid", "new"}},
+		{"size keeps fitting entries", 0, 300, []string{"old", "mid", "new"}},
+		{"ttl and size", 90 * time.Minute, 150, []string{"new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDiskCache(t.TempDir())
+			putAged(t, c, "old", 100, 2*time.Hour)
+			putAged(t, c, "mid", 100, time.Hour)
+			putAged(t, c, "new", 100, time.Minute)
+
+			c.SetLimits(tt.ttl, tt.maxBytes)
+			c.mu.Lock()
+			c.prune()
+			c.mu.Unlock()
+
+			for _, key := range []string{"old", "mid", "new"} {
+				_, err := os.Stat(c.path(key))
+				kept := err == nil
+				want := false
+				for _, k := range tt.want {
+					want = want || k == key
+				}
+				if kept != want {
+					t.Errorf("entry %s kept = %v, want %v", key, kept, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiskCacheGet(t *testing.T) {
+	c := NewDiskCache(t.TempDir())
+	c.SetLimits(time.Hour, 0)
+
+	if err := c.Put("k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := c.Get("k"); !ok || string(data) != "v" {
+		t.Errorf("Get() = %q, %v, want v, true", data, ok)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Get(missing) = true")
+	}
+
+	putAged(t, c, "expired", 1, 2*time.Hour)
+	if _, ok := c.Get("expired"); ok {
+		t.Error("Get(expired) = true")
+	}
+	if _, err := os.Stat(c.path("expired")); !os.IsNotExist(err) {
+		t.Error("expired entry was not removed")
+	}
+}
+
+func TestDiskCacheClear(t *testing.T) {
+	dir := t.TempDir()
+	c := NewDiskCache(dir)
+	if err := c.Put("k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(other, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Error("entry still present after Clear")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("Clear removed a file that is not a cache entry")
+	}
+}
+
+func TestDiskCacheCreatesDirLazily(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c := NewDiskCache(dir)
+
+	if _, ok := c.Get("k"); ok {
+		t.Error("Get on a missing dir = true")
+	}
+	if err := c.Clear(); err != nil {
+		t.Errorf("Clear on a missing dir: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("dir exists before the first Put: %v", err)
+	}
+
+	if err := c.Put("k", []byte("v")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if data, ok := c.Get("k"); !ok || string(data) != "v" {
+		t.Errorf("Get() = %q, %v, want v, true", data, ok)
+	}
+}
+
+func TestDiskCachePruneTempFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		age      time.Duration
+		wantKept bool
+	}{
+		{"fresh temp file is kept", time.Second, true},
+		{"stale temp file is removed", staleTmpAge + time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := NewDiskCache(dir)
+			tmp := filepath.Join(dir, tmpPrefix+"123")
+			if err := os.WriteFile(tmp, []byte("partial"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			old := time.Now().Add(-tt.age)
+			if err := os.Chtimes(tmp, old, old); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.Put("k", []byte("v")); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			_, err := os.Stat(tmp)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Errorf("temp file kept = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
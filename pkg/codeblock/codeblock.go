@@ -0,0 +1,100 @@
+// Package codeblock 解析 Markdown 回答中的围栏代码块，并维护代码语言标记、文件扩展名的对应关系
+package codeblock
+
+import (
+	"strings"
+)
+
+// Block 回答中的一个代码块
+type Block struct {
+	Index     int    `json:"index"`
+	Language  string `json:"language"`
+	Code      string `json:"code"`
+	StartLine int    `json:"startLine"` // 起始围栏所在行（从 1 开始）
+	EndLine   int    `json:"endLine"`   // 结束围栏所在行，未闭合时为最后一行
+}
+
+// Extract 解析 Markdown 中的围栏代码块（``` 或 ~~~）
+func Extract(markdown string) []Block {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	blocks := make([]Block, 0)
+
+	var (
+		inBlock  bool
+		fence    string
+		current  Block
+		codeBody []string
+	)
+
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+
+		if !inBlock {
+			marker := fenceMarker(trimmed)
+			if marker == "" {
+				continue
+			}
+			inBlock = true
+			fence = marker
+			codeBody = codeBody[:0]
+			current = Block{
+				Index:     len(blocks),
+				Language:  parseLanguage(trimmed[len(marker):]),
+				StartLine: i + 1,
+			}
+			continue
+		}
+
+		// 结束围栏：与起始围栏同字符且长度不小于起始围栏，后面不能有其他内容
+		if closing := strings.TrimSpace(trimmed); strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+			current.Code = strings.Join(codeBody, "\n")
+			current.EndLine = i + 1
+			blocks = append(blocks, current)
+			inBlock = false
+			continue
+		}
+		codeBody = append(codeBody, line)
+	}
+
+	// 流式截断等情况下最后一个代码块可能未闭合
+	if inBlock && len(codeBody) > 0 {
+		current.Code = strings.Join(codeBody, "\n")
+		current.EndLine = len(lines)
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+// fenceMarker 返回行首的围栏标记（至少 3 个 ` 或 ~），不是围栏时返回空
+func fenceMarker(line string) string {
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	// 反引号围栏的信息串中不能再出现反引号
+	if line[0] == '`' && strings.Contains(line[n:], "`") {
+		return ""
+	}
+	return line[:n]
+}
+
+// parseLanguage 解析围栏后的信息串，取第一个单词作为语言
+func parseLanguage(info string) string {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return ""
+	}
+	lang := strings.ToLower(fields[0])
+	// 兼容 ```{python} 和 ```python{1,3} 等写法
+	lang = strings.Trim(lang, "{}.")
+	if idx := strings.IndexAny(lang, "{:"); idx >= 0 {
+		lang = lang[:idx]
+	}
+	return lang
+}
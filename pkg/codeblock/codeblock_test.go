@@ -0,0 +1,117 @@
+package codeblock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []Block
+	}{
+		{
+			name:     "none",
+			markdown: "no code here",
+			want:     []Block{},
+		},
+		{
+			name:     "backticks",
+			markdown: "text\n```go\nfmt.Println()\n```\n",
+			want:     []Block{{Index: 0, Language: "go", Code: "fmt.Println()", StartLine: 2, EndLine: 4}},
+		},
+		{
+			name:     "tildes",
+			markdown: "~~~python\nprint(1)\n~~~",
+			want:     []Block{{Language: "python", Code: "print(1)", StartLine: 1, EndLine: 3}},
+		},
+		{
+			name:     "crlf",
+			markdown: "```js\r\na\r\nb\r\n```",
+			want:     []Block{{Language: "js", Code: "a\nb", StartLine: 1, EndLine: 4}},
+		},
+		{
+			name:     "multiple",
+			markdown: "```\na\n```\nmid\n```sh\nb\n```",
+			want: []Block{
+				{Index: 0, Code: "a", StartLine: 1, EndLine: 3},
+				{Index: 1, Language: "sh", Code: "b", StartLine: 5, EndLine: 7},
+			},
+		},
+		{
+			name:     "nested longer fence",
+			markdown: "````markdown\n```go\nx\n```\n````",
+			want:     []Block{{Language: "markdown", Code: "```go\nx\n```", StartLine: 1, EndLine: 5}},
+		},
+		{
+			name:     "tilde not closed by backticks",
+			markdown: "~~~\na\n```\n~~~",
+			want:     []Block{{Code: "a\n```", StartLine: 1, EndLine: 4}},
+		},
+		{
+			name:     "closing fence with text",
+			markdown: "```\na\n``` b\n```",
+			want:     []Block{{Code: "a\n``` b", StartLine: 1, EndLine: 4}},
+		},
+		{
+			name:     "longer closing fence",
+			markdown: "```\na\n`````",
+			want:     []Block{{Code: "a", StartLine: 1, EndLine: 3}},
+		},
+		{
+			name:     "indented",
+			markdown: "  ```cpp\n  int x;\n  ```",
+			want:     []Block{{Language: "cpp", Code: "  int x;", StartLine: 1, EndLine: 3}},
+		},
+		{
+			name:     "inline backticks are not a fence",
+			markdown: "``` a`b\nx",
+			want:     []Block{},
+		},
+		{
+			name:     "two backticks are not a fence",
+			markdown: "``\nx\n``",
+			want:     []Block{},
+		},
+		{
+			name:     "unclosed",
+			markdown: "```py\nprint(1)\nprint(2)",
+			want:     []Block{{Language: "py", Code: "print(1)\nprint(2)", StartLine: 1, EndLine: 3}},
+		},
+		{
+			name:     "unclosed empty",
+			markdown: "text\n```py",
+			want:     []Block{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.markdown); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		info string
+		want string
+	}{
+		{"", ""},
+		{"Go", "go"},
+		{"  python  title=main.py", "python"},
+		{"{python}", "python"},
+		{"python{1,3}", "python"},
+		{"rust:main.rs", "rust"},
+		{"c++", "c++"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			if got := parseLanguage(tt.info); got != tt.want {
+				t.Errorf("parseLanguage(%q) = %q, want %q", tt.info, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package codeblock
+
+import "strings"
+
+// language 一种代码语言：规范名称、代码块中常见的别名与对应的文件扩展名（第一个为保存时使用的扩展名）
+type language struct {
+	name       string
+	aliases    []string
+	extensions []string
+}
+
+// languages 所有已知语言，代码块标记、保存扩展名与附件识别共用这一张表
+var languages = []language{
+	{"python", []string{"py", "python3"}, []string{".py"}},
+	{"go", []string{"golang"}, []string{".go"}},
+	{"java", nil, []string{".java"}},
+	{"c", nil, []string{".c", ".h"}},
+	{"cpp", []string{"c++", "cc", "cxx"}, []string{".cpp", ".cc", ".cxx", ".hpp"}},
+	{"csharp", []string{"cs", "c#"}, []string{".cs"}},
+	{"javascript", []string{"js", "node", "nodejs"}, []string{".js"}},
+	{"typescript", []string{"ts"}, []string{".ts"}},
+	{"jsx", nil, []string{".jsx"}},
+	{"tsx", nil, []string{".tsx"}},
+	{"rust", []string{"rs"}, []string{".rs"}},
+	{"kotlin", []string{"kt"}, []string{".kt"}},
+	{"swift", nil, []string{".swift"}},
+	{"ruby", []string{"rb"}, []string{".rb"}},
+	{"php", nil, []string{".php"}},
+	{"scala", nil, []string{".scala"}},
+	{"sql", []string{"mysql"}, []string{".sql"}},
+	{"bash", []string{"sh", "shell", "zsh"}, []string{".sh"}},
+	{"powershell", []string{"ps1"}, []string{".ps1"}},
+	{"html", nil, []string{".html"}},
+	{"css", nil, []string{".css"}},
+	{"vue", nil, []string{".vue"}},
+	{"json", nil, []string{".json"}},
+	{"yaml", []string{"yml"}, []string{".yaml", ".yml"}},
+	{"xml", nil, []string{".xml"}},
+	{"markdown", []string{"md"}, []string{".md"}},
+	{"lua", nil, []string{".lua"}},
+	{"r", nil, []string{".r"}},
+	{"dart", nil, []string{".dart"}},
+	{"haskell", nil, []string{".hs"}},
+}
+
+// 按别名与扩展名建立的索引
+var (
+	byTag       = make(map[string]*language)
+	byExtension = make(map[string]*language)
+)
+
+func init() {
+	for i := range languages {
+		lang := &languages[i]
+		byTag[lang.name] = lang
+		for _, alias := range lang.aliases {
+			byTag[alias] = lang
+		}
+		for _, ext := range lang.extensions {
+			byExtension[ext] = lang
+		}
+	}
+}
+
+// Language 返回代码块语言标记对应的规范名称（如 "py" -> "python"），未知语言返回空
+func Language(tag string) string {
+	if lang, ok := byTag[strings.ToLower(strings.TrimSpace(tag))]; ok {
+		return lang.name
+	}
+	return ""
+}
+
+// Extension 返回语言标记对应的文件扩展名，未知语言返回 .txt
+func Extension(tag string) string {
+	if lang, ok := byTag[strings.ToLower(strings.TrimSpace(tag))]; ok {
+		return lang.extensions[0]
+	}
+	return ".txt"
+}
+
+// LanguageForExtension 返回文件扩展名（含点）对应的语言标记，未知扩展名返回空
+func LanguageForExtension(ext string) string {
+	if lang, ok := byExtension[strings.ToLower(ext)]; ok {
+		return lang.name
+	}
+	return ""
+}
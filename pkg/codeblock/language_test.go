@@ -0,0 +1,52 @@
+package codeblock
+
+import "testing"
+
+func TestLanguageTable(t *testing.T) {
+	tests := []struct {
+		tag      string
+		language string
+		ext      string
+	}{
+		{"python", "python", ".py"},
+		{"Py", "python", ".py"},
+		{"golang", "go", ".go"},
+		{"c++", "cpp", ".cpp"},
+		{"nodejs", "javascript", ".js"},
+		{"yml", "yaml", ".yaml"},
+		{" sh ", "bash", ".sh"},
+		{"unknown", "", ".txt"},
+		{"", "", ".txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if got := Language(tt.tag); got != tt.language {
+				t.Errorf("Language(%q) = %q, want %q", tt.tag, got, tt.language)
+			}
+			if got := Extension(tt.tag); got != tt.ext {
+				t.Errorf("Extension(%q) = %q, want %q", tt.tag, got, tt.ext)
+			}
+		})
+	}
+}
+
+func TestLanguageForExtension(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{".go", "go"},
+		{".h", "c"},
+		{".HPP", "cpp"},
+		{".yml", "yaml"},
+		{".vue", "vue"},
+		{".unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			if got := LanguageForExtension(tt.ext); got != tt.want {
+				t.Errorf("LanguageForExtension(%q) = %q, want %q", tt.ext, got, tt.want)
+			}
+		})
+	}
+}
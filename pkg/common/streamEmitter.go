@@ -0,0 +1,139 @@
+package common
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStreamFlushSize 单个事件缓冲的文本达到该字节数时立即发送
+const DefaultStreamFlushSize = 4096
+
+// StreamEmitter 合并流式文本事件
+// 一个间隔内连续到达的同名事件片段拼接后一次发送，减少前端 IPC 调用次数
+// 缓冲按到达顺序保存，只合并相邻的同名事件；非流式事件经 Emit 发送前会先发送缓冲内容，保证前端收到的顺序不变
+type StreamEmitter struct {
+	emit      func(event string, data ...interface{})
+	interval  time.Duration
+	flushSize int
+
+	mu      sync.Mutex
+	pending []pendingEvent // 按到达顺序缓冲的事件
+	size    int            // 缓冲文本的总字节数
+	timer   *time.Timer
+	closed  bool
+
+	sendMu sync.Mutex // 保证各批事件按取出的顺序发送，发送时不持有 mu
+}
+
+// pendingEvent 一段合并后的缓冲事件
+type pendingEvent struct {
+	event string
+	text  *strings.Builder
+}
+
+// NewStreamEmitter 创建合并发送器，interval <= 0 时不做合并，flushSize <= 0 时使用默认值
+func NewStreamEmitter(emit func(event string, data ...interface{}), interval time.Duration, flushSize int) *StreamEmitter {
+	if flushSize <= 0 {
+		flushSize = DefaultStreamFlushSize
+	}
+	return &StreamEmitter{
+		emit:      emit,
+		interval:  interval,
+		flushSize: flushSize,
+	}
+}
+
+// Append 缓冲一个文本片段，到达间隔或缓冲过大时发送
+func (e *StreamEmitter) Append(event, text string) {
+	if e == nil || e.emit == nil || text == "" {
+		return
+	}
+
+	e.mu.Lock()
+	// 未开启合并或已关闭时直接发送
+	if e.interval <= 0 || e.closed {
+		batch := e.takeLocked()
+		e.send(batch, func() { e.emit(event, text) })
+		return
+	}
+
+	if n := len(e.pending); n > 0 && e.pending[n-1].event == event {
+		e.pending[n-1].text.WriteString(text)
+	} else {
+		buf := &strings.Builder{}
+		buf.WriteString(text)
+		e.pending = append(e.pending, pendingEvent{event: event, text: buf})
+	}
+	e.size += len(text)
+
+	if e.size >= e.flushSize {
+		batch := e.takeLocked()
+		e.send(batch, nil)
+		return
+	}
+	if e.timer == nil {
+		e.timer = time.AfterFunc(e.interval, e.Flush)
+	}
+	e.mu.Unlock()
+}
+
+// Emit 发送非流式事件，发送前先发送缓冲内容
+func (e *StreamEmitter) Emit(event string, data ...interface{}) {
+	if e == nil || e.emit == nil {
+		return
+	}
+
+	e.mu.Lock()
+	batch := e.takeLocked()
+	e.send(batch, func() { e.emit(event, data...) })
+}
+
+// Flush 立即发送所有缓冲内容
+func (e *StreamEmitter) Flush() {
+	if e == nil || e.emit == nil {
+		return
+	}
+
+	e.mu.Lock()
+	batch := e.takeLocked()
+	e.send(batch, nil)
+}
+
+// Close 发送剩余内容，之后的片段不再缓冲
+func (e *StreamEmitter) Close() {
+	if e == nil || e.emit == nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.closed = true
+	batch := e.takeLocked()
+	e.send(batch, nil)
+}
+
+// takeLocked 取出全部缓冲内容并停止计时器，调用方需持有 mu
+func (e *StreamEmitter) takeLocked() []pendingEvent {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	batch := e.pending
+	e.pending, e.size = nil, 0
+	return batch
+}
+
+// send 在释放 mu 后按顺序发送取出的缓冲内容，然后执行 then（可为 nil）
+// 调用方需持有 mu，先取得 sendMu 再释放 mu，保证先取出的批次先发送
+func (e *StreamEmitter) send(batch []pendingEvent, then func()) {
+	e.sendMu.Lock()
+	e.mu.Unlock()
+	defer e.sendMu.Unlock()
+
+	for i := range batch {
+		e.emit(batch[i].event, batch[i].text.String())
+	}
+	if then != nil {
+		then()
+	}
+}
@@ -0,0 +1,160 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder 记录发送的事件
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) emit(event string, data ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprint(append([]interface{}{event + ":"}, data...)...))
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestStreamEmitterOrdering(t *testing.T) {
+	type step struct {
+		op    string // append、emit、flush 或 close
+		event string
+		text  string
+	}
+	tests := []struct {
+		name     string
+		interval time.Duration
+		steps    []step
+		want     []string
+	}{
+		{
+			name:     "merges adjacent events",
+			interval: time.Hour,
+			steps:    []step{{"append", "chunk", "a"}, {"append", "chunk", "b"}, {"flush", "", ""}},
+			want:     []string{"chunk:ab"},
+		},
+		{
+			name:     "keeps interleaved order",
+			interval: time.Hour,
+			steps: []step{
+				{"append", "thinking", "t1"}, {"append", "chunk", "c1"},
+				{"append", "thinking", "t2"}, {"append", "chunk", "c2"}, {"flush", "", ""},
+			},
+			want: []string{"thinking:t1", "chunk:c1", "thinking:t2", "chunk:c2"},
+		},
+		{
+			name:     "emit sends pending first",
+			interval: time.Hour,
+			steps:    []step{{"append", "chunk", "a"}, {"emit", "done", ""}},
+			want:     []string{"chunk:a", "done:"},
+		},
+		{
+			name:     "no interval sends immediately",
+			interval: 0,
+			steps:    []step{{"append", "chunk", "a"}, {"append", "chunk", "b"}},
+			want:     []string{"chunk:a", "chunk:b"},
+		},
+		{
+			name:     "close sends pending and stops buffering",
+			interval: time.Hour,
+			steps:    []step{{"append", "chunk", "a"}, {"close", "", ""}, {"append", "chunk", "b"}},
+			want:     []string{"chunk:a", "chunk:b"},
+		},
+		{
+			name:     "empty text is ignored",
+			interval: time.Hour,
+			steps:    []step{{"append", "chunk", ""}, {"flush", "", ""}},
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r recorder
+			e := NewStreamEmitter(r.emit, tt.interval, 0)
+			for _, s := range tt.steps {
+				switch s.op {
+				case "append":
+					e.Append(s.event, s.text)
+				case "emit":
+					e.Emit(s.event)
+				case "flush":
+					e.Flush()
+				case "close":
+					e.Close()
+				}
+			}
+			if got := r.get(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamEmitterFlushSize(t *testing.T) {
+	var r recorder
+	e := NewStreamEmitter(r.emit, time.Hour, 4)
+
+	e.Append("chunk", "ab")
+	if got := r.get(); len(got) != 0 {
+		t.Fatalf("events = %q, want nothing before reaching flush size", got)
+	}
+	e.Append("chunk", "cd")
+	if got := r.get(); !reflect.DeepEqual(got, []string{"chunk:abcd"}) {
+		t.Errorf("events = %q, want one merged chunk", got)
+	}
+}
+
+func TestStreamEmitterTimer(t *testing.T) {
+	var r recorder
+	e := NewStreamEmitter(r.emit, 10*time.Millisecond, 0)
+	e.Append("chunk", "a")
+
+	deadline := time.Now().Add(time.Second)
+	for len(r.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := r.get(); !reflect.DeepEqual(got, []string{"chunk:a"}) {
+		t.Errorf("events = %q, want chunk sent by timer", got)
+	}
+}
+
+func TestStreamEmitterConcurrentOrder(t *testing.T) {
+	var r recorder
+	e := NewStreamEmitter(r.emit, time.Millisecond, 8)
+
+	// 单个生产者并发触发计时器与按大小发送，拼接结果必须与写入顺序一致
+	var want string
+	for i := 0; i < 500; i++ {
+		text := fmt.Sprintf("%d,", i)
+		want += text
+		e.Append("chunk", text)
+	}
+	e.Close()
+
+	var got string
+	for _, ev := range r.get() {
+		got += ev[len("chunk:"):]
+	}
+	if got != want {
+		t.Errorf("concatenated output out of order")
+	}
+}
+
+func TestStreamEmitterNil(t *testing.T) {
+	var e *StreamEmitter
+	e.Append("chunk", "a")
+	e.Emit("done")
+	e.Flush()
+	e.Close()
+}
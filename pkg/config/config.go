@@ -3,7 +3,9 @@ package config
 import (
 	"Q-Solver/pkg/shortcut"
 	"encoding/json"
+	"net/url"
 	"runtime"
+	"strings"
 )
 
 type Config struct {
@@ -19,7 +21,8 @@ type Config struct {
 	Grayscale          bool                           `json:"grayscale,omitempty"`
 	KeepContext        bool                           `json:"keepContext,omitempty"`
 	InterruptThinking  bool                           `json:"interruptThinking,omitempty"`
-	ScreenshotMode     string                         `json:"screenshotMode,omitempty"`
+	ScreenshotMode     string                         `json:"screenshotMode,omitempty"`   // "fullscreen" 主屏幕，"window" 本窗口区域，"region" 指定区域
+	ScreenshotRegion   *CaptureRegion                 `json:"screenshotRegion,omitempty"` // 区域截图模式下的截图区域
 	ResumePath         string                         `json:"resumePath,omitempty"`
 	ResumeBase64       string                         `json:"-"`
 	ResumeContent      string                         `json:"resumeContent,omitempty"`
@@ -33,17 +36,90 @@ type Config struct {
 	MaxTokens      int     `json:"maxTokens,omitempty"`
 	ThinkingBudget int     `json:"thinkingBudget,omitempty"`
 
-	// 辅助模型（用于总结对话生成问题导图）
+	// 解题方案（按题型切换提示词、模型和参数），ActiveProfile 为空时使用主配置
+	Profiles      []SolveProfile `json:"profiles,omitempty"`
+	ActiveProfile string         `json:"activeProfile,omitempty"`
+
+	// 多次采样投票（选择题与数值题）：并行采样多次，按提取出的最终答案多数表决
+	ConsistencySamples     int `json:"consistencySamples,omitempty"`     // 采样次数
+	ConsistencyConcurrency int `json:"consistencyConcurrency,omitempty"` // 同时进行的请求数
+
+	// 改进动作（针对上一个回答追问，可绑定快捷键；null 表示使用默认动作，空列表表示不使用任何动作）
+	RefineActions []RefineAction `json:"refineActions"`
+
+	// 自动路由：截图解题前由辅助模型识别题型，切换到 Category 对应的解题方案（方案快捷键不参与路由）
+	AutoRoute bool `json:"autoRoute,omitempty"`
+
+	// 上下文窗口管理（KeepContext 开启时，每次请求前按预算自动压缩历史消息）
+	ContextBudget   int    `json:"contextBudget"`             // 历史消息的 Token 预算，0 表示不限制（不省略零值，避免被默认值覆盖）
+	ContextStrategy string `json:"contextStrategy,omitempty"` // 超出预算时："drop_images" 省略旧截图，"summarize" 由辅助模型总结早期对话，"sliding_window" 丢弃最早的问答
+
+	// 语言偏好（追加到解题、实时对话与简历解析的提示词中，无需手动修改 Prompt）
+	AnswerLanguage        string   `json:"answerLanguage,omitempty"`        // 回答语言："zh" 中文，"en" 英文，为空时跟随提示词
+	CodeLanguages         []string `json:"codeLanguages,omitempty"`         // 偏好的编程语言，按优先级排列
+	ReaskOnLanguageSwitch bool     `json:"reaskOnLanguageSwitch,omitempty"` // 快捷键切换回答语言后用新语言重新回答上一题
+
+	// 历史截图（KeepContext 开启时，只保留最近几张截图的原图，更早的截图缩小或替换为文字，减少重复发送）
+	HistoryImagePolicy string `json:"historyImagePolicy,omitempty"` // "keep" 全部保留原图（默认），"thumbnail" 缩略图，"placeholder" 文字占位并附上当时的回答摘要
+	HistoryImageKeep   int    `json:"historyImageKeep"`             // 保留原图的最近截图数（不省略零值）
+
+	// 解题记录中保存截图与图片附件（不省略 false），关闭后以文字占位，只保存问答文字
+	HistoryScreenshots bool `json:"historyScreenshots"`
+
+	// 流式输出事件合并间隔（毫秒），期间到达的片段合并为一次事件发送给前端，0 表示逐片发送
+	StreamFlushInterval int `json:"streamFlushInterval"`
+
+	// 辅助模型（用于总结对话生成问题导图、解题自检、压缩长对话）
 	AssistantModel string `json:"assistantModel,omitempty"`
+	SelfReview     bool   `json:"selfReview,omitempty"` // 解题完成后由辅助模型审查回答
+
+	// 快慢双模型：同时请求快速模型和主模型，先展示快速模型的回答
+	SpeculativeSolve   bool   `json:"speculativeSolve,omitempty"`
+	FastModel          string `json:"fastModel,omitempty"`
+	SpeculativeDisplay string `json:"speculativeDisplay,omitempty"` // 主模型回答到达后："replace" 替换快速回答，"alongside" 并列展示
 
 	// Live API
 	UseLiveApi bool `json:"useLiveApi,omitempty"`
 
+	// 网络设置（所有 Provider 与 Live 连接共用）
+	Proxy          string            `json:"proxy,omitempty"`          // 代理地址，留空或 "system" 使用系统代理，"direct" 直连，支持 http/https/socks5
+	ConnectTimeout int               `json:"connectTimeout,omitempty"` // 连接超时（秒），0 表示不限制
+	ReadTimeout    int               `json:"readTimeout,omitempty"`    // 读取超时（秒），两次收到数据的最长间隔，0 表示不限制
+	CACertPath     string            `json:"caCertPath,omitempty"`     // 自定义 CA 证书路径（PEM）
+	ExtraHeaders   map[string]string `json:"extraHeaders,omitempty"`   // 附加请求头
+
+	// 多模型对比：同一截图并行发送给多个模型
+	CompareTargets []CompareTarget `json:"compareTargets,omitempty"`
+
+	// 响应缓存（相同截图与参数的重复请求直接返回缓存结果）
+	ResponseCache      bool `json:"responseCache,omitempty"`
+	ResponseCacheTTL   int  `json:"responseCacheTTL,omitempty"`   // 过期时间（分钟），0 表示不过期
+	ResponseCacheMaxMB int  `json:"responseCacheMaxMB,omitempty"` // 缓存目录容量上限（MB），0 表示不限制
+
+	// 本地运行生成的代码（样例测试）
+	RunnerTimeout  int `json:"runnerTimeout,omitempty"`  // 单个样例的运行超时（秒）
+	RunnerMemoryMB int `json:"runnerMemoryMB,omitempty"` // 内存上限（MB），0 表示不限制
+
+	// 可观测性（OpenTelemetry 链路追踪与指标）
+	TelemetryExporter string `json:"telemetryExporter,omitempty"` // 导出方式：留空关闭，"otlp" 或 "file"
+	TelemetryEndpoint string `json:"telemetryEndpoint,omitempty"` // OTLP/HTTP 地址，如 http://localhost:4318
+	TelemetryFile     string `json:"telemetryFile,omitempty"`     // 本地文件路径，留空则写入配置目录
+
 	// 窗口尺寸
 	WindowWidth  int `json:"windowWidth,omitempty"`
 	WindowHeight int `json:"windowHeight,omitempty"`
 }
 
+// CompareTarget 多模型对比中的一个模型
+// APIKey 和 BaseURL 为空时沿用主配置
+type CompareTarget struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model"`
+	APIKey   string `json:"apiKey,omitempty"`
+	BaseURL  string `json:"baseURL,omitempty"`
+}
+
 const DefaultModel = "gemini-2.5-flash"
 
 func NewDefaultConfig() Config {
@@ -75,12 +151,59 @@ func NewDefaultConfig() Config {
 		MaxTokens:      8192,
 		ThinkingBudget: 16000,
 
+		// 多次采样投票
+		ConsistencySamples:     5,
+		ConsistencyConcurrency: 3,
+
+		// 改进动作
+		RefineActions: DefaultRefineActions(),
+
+		// 上下文窗口管理
+		ContextBudget:   0,
+		ContextStrategy: "drop_images",
+
+		// 历史截图
+		HistoryImagePolicy: "keep",
+		HistoryImageKeep:   2,
+
+		// 解题记录
+		HistoryScreenshots: true,
+
+		// 流式输出
+		StreamFlushInterval: 40,
+
 		// 辅助模型
 		AssistantModel: "",
+		SelfReview:     false,
+
+		// 快慢双模型
+		SpeculativeSolve:   false,
+		FastModel:          "",
+		SpeculativeDisplay: "replace",
 
 		// Live API
 		UseLiveApi: false,
 
+		// 网络设置
+		Proxy:          "",
+		ConnectTimeout: 0,
+		ReadTimeout:    0,
+		CACertPath:     "",
+
+		// 响应缓存
+		ResponseCache:      false,
+		ResponseCacheTTL:   60,
+		ResponseCacheMaxMB: 200,
+
+		// 本地运行代码
+		RunnerTimeout:  5,
+		RunnerMemoryMB: 256,
+
+		// 可观测性
+		TelemetryExporter: "",
+		TelemetryEndpoint: "",
+		TelemetryFile:     "",
+
 		// 窗口尺寸默认值
 		WindowWidth:  0,
 		WindowHeight: 0,
@@ -92,28 +215,38 @@ func getDefaultShortcuts() map[string]shortcut.KeyBinding {
 	if runtime.GOOS == "darwin" {
 		// macOS 使用简化的快捷键（不依赖 Windows VK 码）
 		return map[string]shortcut.KeyBinding{
-			"solve":        {ComboID: "Cmd+1", KeyName: "⌘1"},
-			"toggle":       {ComboID: "Cmd+2", KeyName: "⌘2"},
-			"clickthrough": {ComboID: "Cmd+3", KeyName: "⌘3"},
-			"move_up":      {ComboID: "Cmd+Option+Up", KeyName: "⌘⌥↑"},
-			"move_down":    {ComboID: "Cmd+Option+Down", KeyName: "⌘⌥↓"},
-			"move_left":    {ComboID: "Cmd+Option+Left", KeyName: "⌘⌥←"},
-			"move_right":   {ComboID: "Cmd+Option+Right", KeyName: "⌘⌥→"},
-			"scroll_up":    {ComboID: "Cmd+Option+Shift+Up", KeyName: "⌘⌥⇧↑"},
-			"scroll_down":  {ComboID: "Cmd+Option+Shift+Down", KeyName: "⌘⌥⇧↓"},
+			"solve":           {ComboID: "Cmd+1", KeyName: "⌘1"},
+			"toggle":          {ComboID: "Cmd+2", KeyName: "⌘2"},
+			"clickthrough":    {ComboID: "Cmd+3", KeyName: "⌘3"},
+			"add_page":        {ComboID: "Cmd+4", KeyName: "⌘4"},
+			"solve_batch":     {ComboID: "Cmd+5", KeyName: "⌘5"},
+			"solve_clipboard": {ComboID: "Cmd+6", KeyName: "⌘6"},
+			"toggle_language": {ComboID: "Cmd+7", KeyName: "⌘7"},
+			"solve_vote":      {ComboID: "Cmd+8", KeyName: "⌘8"},
+			"move_up":         {ComboID: "Cmd+Option+Up", KeyName: "⌘⌥↑"},
+			"move_down":       {ComboID: "Cmd+Option+Down", KeyName: "⌘⌥↓"},
+			"move_left":       {ComboID: "Cmd+Option+Left", KeyName: "⌘⌥←"},
+			"move_right":      {ComboID: "Cmd+Option+Right", KeyName: "⌘⌥→"},
+			"scroll_up":       {ComboID: "Cmd+Option+Shift+Up", KeyName: "⌘⌥⇧↑"},
+			"scroll_down":     {ComboID: "Cmd+Option+Shift+Down", KeyName: "⌘⌥⇧↓"},
 		}
 	}
 	// Windows 默认快捷键
 	return map[string]shortcut.KeyBinding{
-		"solve":        {ComboID: "119", KeyName: "F8"},
-		"toggle":       {ComboID: "120", KeyName: "F9"},
-		"clickthrough": {ComboID: "121", KeyName: "F10"},
-		"move_up":      {ComboID: "38+164", KeyName: "Alt+↑"},
-		"move_down":    {ComboID: "40+164", KeyName: "Alt+↓"},
-		"move_left":    {ComboID: "37+164", KeyName: "Alt+←"},
-		"move_right":   {ComboID: "39+164", KeyName: "Alt+→"},
-		"scroll_up":    {ComboID: "33+164", KeyName: "Alt+PgUp"},
-		"scroll_down":  {ComboID: "34+164", KeyName: "Alt+PgDn"},
+		"solve":           {ComboID: "119", KeyName: "F8"},
+		"toggle":          {ComboID: "120", KeyName: "F9"},
+		"clickthrough":    {ComboID: "121", KeyName: "F10"},
+		"add_page":        {ComboID: "117", KeyName: "F6"},
+		"solve_batch":     {ComboID: "118", KeyName: "F7"},
+		"solve_clipboard": {ComboID: "86+164", KeyName: "Alt+V"},
+		"toggle_language": {ComboID: "76+164", KeyName: "Alt+L"},
+		"solve_vote":      {ComboID: "77+164", KeyName: "Alt+M"},
+		"move_up":         {ComboID: "38+164", KeyName: "Alt+↑"},
+		"move_down":       {ComboID: "40+164", KeyName: "Alt+↓"},
+		"move_left":       {ComboID: "37+164", KeyName: "Alt+←"},
+		"move_right":      {ComboID: "39+164", KeyName: "Alt+→"},
+		"scroll_up":       {ComboID: "33+164", KeyName: "Alt+PgUp"},
+		"scroll_down":     {ComboID: "34+164", KeyName: "Alt+PgDn"},
 	}
 }
 
@@ -122,9 +255,22 @@ func (c *Config) ToJSON() string {
 	return string(data)
 }
 
+// clone 深拷贝配置（不含不序列化的字段），切片和 map 不与原配置共享底层数据
+func (c Config) clone() (Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return Config{}, err
+	}
+	var copied Config
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return Config{}, err
+	}
+	return copied, nil
+}
+
 func (c *Config) Validate() error {
-	if c.ScreenshotMode != "" && c.ScreenshotMode != "fullscreen" && c.ScreenshotMode != "window" {
-		return &ValidationError{Field: "screenshotMode", Message: "截图模式必须是 'fullscreen' 或 'window'"}
+	if err := validateScreenshotMode(c.ScreenshotMode, c.ScreenshotRegion, "screenshotMode", ""); err != nil {
+		return err
 	}
 	if c.Opacity < 0 || c.Opacity > 1 {
 		return &ValidationError{Field: "opacity", Message: "透明度必须在 0-1 之间"}
@@ -132,9 +278,94 @@ func (c *Config) Validate() error {
 	if c.CompressionQuality < 1 || c.CompressionQuality > 100 {
 		return &ValidationError{Field: "compressionQuality", Message: "压缩质量必须在 1-100 之间"}
 	}
+	if err := validateProxy(c.Proxy); err != nil {
+		return err
+	}
+	if c.ConnectTimeout < 0 || c.ReadTimeout < 0 {
+		return &ValidationError{Field: "timeout", Message: "超时时间不能为负数"}
+	}
+	seen := make(map[string]bool)
+	for _, t := range c.CompareTargets {
+		if t.ID == "" || t.Model == "" {
+			return &ValidationError{Field: "compareTargets", Message: "对比模型的 ID 和模型名称不能为空"}
+		}
+		if seen[t.ID] {
+			return &ValidationError{Field: "compareTargets", Message: "对比模型 ID 重复: " + t.ID}
+		}
+		seen[t.ID] = true
+	}
+	if c.SpeculativeDisplay != "" && c.SpeculativeDisplay != "replace" && c.SpeculativeDisplay != "alongside" {
+		return &ValidationError{Field: "speculativeDisplay", Message: "展示方式必须是 'replace' 或 'alongside'"}
+	}
+	if c.ResponseCacheTTL < 0 || c.ResponseCacheMaxMB < 0 {
+		return &ValidationError{Field: "responseCache", Message: "缓存过期时间和容量不能为负数"}
+	}
+	if c.ContextBudget < 0 {
+		return &ValidationError{Field: "contextBudget", Message: "上下文预算不能为负数"}
+	}
+	switch c.ContextStrategy {
+	case "", "drop_images", "summarize", "sliding_window":
+	default:
+		return &ValidationError{Field: "contextStrategy", Message: "上下文策略必须是 'drop_images'、'summarize' 或 'sliding_window'"}
+	}
+	if c.AnswerLanguage != "" && c.AnswerLanguage != "zh" && c.AnswerLanguage != "en" {
+		return &ValidationError{Field: "answerLanguage", Message: "回答语言必须是 'zh' 或 'en'"}
+	}
+	switch c.HistoryImagePolicy {
+	case "", "keep", "thumbnail", "placeholder":
+	default:
+		return &ValidationError{Field: "historyImagePolicy", Message: "历史截图策略必须是 'keep'、'thumbnail' 或 'placeholder'"}
+	}
+	if c.HistoryImageKeep < 0 {
+		return &ValidationError{Field: "historyImageKeep", Message: "保留原图的截图数不能为负数"}
+	}
+	if c.StreamFlushInterval < 0 || c.StreamFlushInterval > 1000 {
+		return &ValidationError{Field: "streamFlushInterval", Message: "流式输出合并间隔必须在 0 到 1000 毫秒之间"}
+	}
+	if c.ConsistencySamples < 2 || c.ConsistencySamples > 15 {
+		return &ValidationError{Field: "consistencySamples", Message: "采样次数必须在 2 到 15 之间"}
+	}
+	if c.ConsistencyConcurrency < 1 {
+		return &ValidationError{Field: "consistencyConcurrency", Message: "并发请求数至少为 1"}
+	}
+	if err := c.validateProfiles(); err != nil {
+		return err
+	}
+	if err := c.validateRefineActions(); err != nil {
+		return err
+	}
+	if c.RunnerTimeout < 0 || c.RunnerMemoryMB < 0 {
+		return &ValidationError{Field: "runner", Message: "运行超时和内存上限不能为负数"}
+	}
+	if c.TelemetryExporter != "" && c.TelemetryExporter != "otlp" && c.TelemetryExporter != "file" {
+		return &ValidationError{Field: "telemetryExporter", Message: "导出方式必须是 'otlp' 或 'file'"}
+	}
 	return nil
 }
 
+// 代理配置的特殊取值
+const (
+	ProxySystem = "system" // 使用系统代理（环境变量 HTTP_PROXY / HTTPS_PROXY / NO_PROXY），留空时同样如此
+	ProxyDirect = "direct" // 强制直连
+)
+
+// validateProxy 校验代理地址
+func validateProxy(proxy string) error {
+	proxy = strings.TrimSpace(proxy)
+	if proxy == "" || proxy == ProxySystem || proxy == ProxyDirect {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return &ValidationError{Field: "proxy", Message: "代理地址格式错误，例如 http://127.0.0.1:7890 或 socks5://127.0.0.1:1080"}
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+	return &ValidationError{Field: "proxy", Message: "代理协议仅支持 http、https、socks5"}
+}
+
 type ValidationError struct {
 	Field   string
 	Message string
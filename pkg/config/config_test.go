@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	region := &CaptureRegion{X: 0, Y: 0, Width: 100, Height: 100}
+
+	tests := []struct {
+		name      string
+		modify    func(c *Config)
+		wantField string // 为空表示校验通过
+	}{
+		{"default", func(c *Config) {}, ""},
+		{"opacity too large", func(c *Config) { c.Opacity = 1.5 }, "opacity"},
+		{"compression quality zero", func(c *Config) { c.CompressionQuality = 0 }, "compressionQuality"},
+		{"unknown screenshot mode", func(c *Config) { c.ScreenshotMode = "tab" }, "screenshotMode"},
+		{"region without area", func(c *Config) { c.ScreenshotMode = "region" }, "screenshotMode"},
+		{"region with area", func(c *Config) { c.ScreenshotMode, c.ScreenshotRegion = "region", region }, ""},
+		{"proxy direct", func(c *Config) { c.Proxy = ProxyDirect }, ""},
+		{"proxy socks5", func(c *Config) { c.Proxy = "socks5://127.0.0.1:1080" }, ""},
+		{"proxy without host", func(c *Config) { c.Proxy = "127.0.0.1:7890" }, "proxy"},
+		{"proxy unsupported scheme", func(c *Config) { c.Proxy = "ftp://127.0.0.1:21" }, "proxy"},
+		{"negative timeout", func(c *Config) { c.ReadTimeout = -1 }, "timeout"},
+		{"compare target without model", func(c *Config) { c.CompareTargets = []CompareTarget{{ID: "a"}} }, "compareTargets"},
+		{"duplicate compare target", func(c *Config) {
+			c.CompareTargets = []CompareTarget{{ID: "a", Model: "m"}, {ID: "a", Model: "n"}}
+		}, "compareTargets"},
+		{"unknown context strategy", func(c *Config) { c.ContextStrategy = "truncate" }, "contextStrategy"},
+		{"negative context budget", func(c *Config) { c.ContextBudget = -1 }, "contextBudget"},
+		{"unknown history image policy", func(c *Config) { c.HistoryImagePolicy = "blur" }, "historyImagePolicy"},
+		{"stream flush interval too large", func(c *Config) { c.StreamFlushInterval = 2000 }, "streamFlushInterval"},
+		{"too few samples", func(c *Config) { c.ConsistencySamples = 1 }, "consistencySamples"},
+		{"zero concurrency", func(c *Config) { c.ConsistencyConcurrency = 0 }, "consistencyConcurrency"},
+		{"profile without name", func(c *Config) { c.Profiles = []SolveProfile{{ID: "p"}} }, "profiles"},
+		{"duplicate profile", func(c *Config) {
+			c.Profiles = []SolveProfile{{ID: "p", Name: "a"}, {ID: "p", Name: "b"}}
+		}, "profiles"},
+		{"profile region falls back to main region", func(c *Config) {
+			c.ScreenshotRegion = region
+			c.Profiles = []SolveProfile{{ID: "p", Name: "a", ScreenshotMode: "region"}}
+		}, ""},
+		{"profile region missing", func(c *Config) {
+			c.Profiles = []SolveProfile{{ID: "p", Name: "a", ScreenshotMode: "region"}}
+		}, "profiles"},
+		{"unknown category", func(c *Config) {
+			c.Profiles = []SolveProfile{{ID: "p", Name: "a", Category: "poetry"}}
+		}, "profiles"},
+		{"duplicate category", func(c *Config) {
+			c.Profiles = []SolveProfile{{ID: "p", Name: "a", Category: CategoryMath}, {ID: "q", Name: "b", Category: CategoryMath}}
+		}, "profiles"},
+		{"missing active profile", func(c *Config) { c.ActiveProfile = "p" }, "activeProfile"},
+		{"refine action without prompt", func(c *Config) {
+			c.RefineActions = []RefineAction{{ID: "r", Name: "r"}}
+		}, "refineActions"},
+		{"negative runner memory", func(c *Config) { c.RunnerMemoryMB = -1 }, "runner"},
+		{"unknown telemetry exporter", func(c *Config) { c.TelemetryExporter = "stdout" }, "telemetryExporter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Field != tt.wantField {
+				t.Errorf("Validate() = %v, want error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	temperature := 0.2
+	budget := 0
+	noResume := false
+	region := CaptureRegion{X: 1, Y: 2, Width: 3, Height: 4}
+
+	base := NewDefaultConfig()
+	base.Model = "base-model"
+	base.Prompt = "base prompt"
+	base.Temperature = 1
+	base.ThinkingBudget = 1024
+	base.ResumePath = "resume.pdf"
+	base.ResumeBase64 = "data"
+	base.Profiles = []SolveProfile{
+		{ID: "empty", Name: "空方案"},
+		{
+			ID: "full", Name: "完整方案",
+			Prompt: "profile prompt", Model: "profile-model",
+			Temperature: &temperature, ThinkingBudget: &budget,
+			ScreenshotMode: "region", ScreenshotRegion: &region,
+			AttachResume: &noResume,
+		},
+	}
+
+	tests := []struct {
+		name  string
+		id    string
+		check func(t *testing.T, got Config)
+	}{
+		{"no profile", "", func(t *testing.T, got Config) {
+			if got.Model != "base-model" || got.Prompt != "base prompt" {
+				t.Errorf("config changed without a profile: %q %q", got.Model, got.Prompt)
+			}
+		}},
+		{"unknown profile", "missing", func(t *testing.T, got Config) {
+			if got.Model != "base-model" {
+				t.Errorf("Model = %q, want base-model", got.Model)
+			}
+		}},
+		{"empty profile keeps main config", "empty", func(t *testing.T, got Config) {
+			if got.Model != "base-model" || got.Temperature != 1 || got.ThinkingBudget != 1024 || got.ResumePath == "" {
+				t.Errorf("empty profile changed config: %q %v %d %q", got.Model, got.Temperature, got.ThinkingBudget, got.ResumePath)
+			}
+		}},
+		{"full profile overrides", "full", func(t *testing.T, got Config) {
+			if got.Model != "profile-model" || got.Prompt != "profile prompt" {
+				t.Errorf("Model %q Prompt %q, want profile values", got.Model, got.Prompt)
+			}
+			if got.Temperature != 0.2 {
+				t.Errorf("Temperature = %v, want 0.2", got.Temperature)
+			}
+			// 显式设置为 0 的指针字段同样覆盖
+			if got.ThinkingBudget != 0 {
+				t.Errorf("ThinkingBudget = %d, want 0", got.ThinkingBudget)
+			}
+			if got.ScreenshotMode != "region" || got.ScreenshotRegion == nil || *got.ScreenshotRegion != region {
+				t.Errorf("screenshot %q %v, want profile region", got.ScreenshotMode, got.ScreenshotRegion)
+			}
+			if got.ScreenshotRegion == &region {
+				t.Error("ScreenshotRegion shares the profile's pointer")
+			}
+			if got.ResumePath != "" || got.ResumeBase64 != "" {
+				t.Error("resume was not removed")
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, base.WithProfile(tt.id))
+		})
+	}
+
+	if base.Model != "base-model" || base.ResumePath != "resume.pdf" {
+		t.Error("WithProfile modified the original config")
+	}
+}
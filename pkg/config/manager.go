@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -49,6 +51,11 @@ func (cm *ConfigManager) getConfigPath() string {
 	return fullPath
 }
 
+// GetConfigDir 返回配置文件所在目录（其他模块的本地数据也存放在这里）
+func (cm *ConfigManager) GetConfigDir() string {
+	return filepath.Dir(cm.configPath)
+}
+
 func (cm *ConfigManager) Load() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
@@ -95,14 +102,29 @@ func (cm *ConfigManager) Get() Config {
 	return cm.config
 }
 
-// UpdateFromJSON 从前端 JSON 全量更新配置
+// UpdateFromJSON 从前端 JSON 更新配置
+// JSON 中出现的字段整体替换（map 与切片不与原值合并），未出现的字段保持原值，校验通过后才会生效
 func (cm *ConfigManager) UpdateFromJSON(jsonStr string) error {
-	var newConfig Config
-	if err := json.Unmarshal([]byte(jsonStr), &newConfig); err != nil {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(jsonStr), &fields); err != nil {
 		return fmt.Errorf("解析配置 JSON 失败: %w", err)
 	}
 
 	cm.mu.Lock()
+	newConfig, err := cm.config.clone()
+	if err == nil {
+		resetFields(&newConfig, fields)
+		err = json.Unmarshal([]byte(jsonStr), &newConfig)
+	}
+	if err != nil {
+		cm.mu.Unlock()
+		return fmt.Errorf("解析配置 JSON 失败: %w", err)
+	}
+	if err := newConfig.Validate(); err != nil {
+		cm.mu.Unlock()
+		return err
+	}
+
 	cm.oldConfig = cm.config //保存当前配置为之前的配置
 	cm.config = newConfig
 	configCopy := cm.config
@@ -118,6 +140,22 @@ func (cm *ConfigManager) UpdateFromJSON(jsonStr string) error {
 	return cm.Save()
 }
 
+// resetFields 将 JSON 中出现的顶层字段置为零值
+// json.Unmarshal 会把 map 的键、切片元素和指针指向的结构体与原值合并，先清空才能删除条目
+func resetFields(cfg *Config, fields map[string]json.RawMessage) {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if _, ok := fields[name]; ok {
+			v.Field(i).SetZero()
+		}
+	}
+}
+
 func (cm *ConfigManager) Subscribe(callback func(NewConfig Config, oldConfig Config)) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
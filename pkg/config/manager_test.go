@@ -0,0 +1,90 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestManager(t *testing.T, cfg Config) *ConfigManager {
+	t.Helper()
+	return &ConfigManager{
+		config:     cfg,
+		oldConfig:  cfg,
+		configPath: filepath.Join(t.TempDir(), "config.json"),
+	}
+}
+
+func TestUpdateFromJSONReplacesMaps(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.ExtraHeaders = map[string]string{"X-Keep": "1", "X-Remove": "2"}
+	cm := newTestManager(t, cfg)
+
+	err := cm.UpdateFromJSON(`{
+		"shortcuts": {"solve": {"vkCode": "119", "keyName": "F8"}},
+		"extraHeaders": {"X-Keep": "1"}
+	}`)
+	if err != nil {
+		t.Fatalf("UpdateFromJSON: %v", err)
+	}
+
+	got := cm.Get()
+	if len(got.Shortcuts) != 1 {
+		t.Errorf("Shortcuts = %v, want only solve", got.Shortcuts)
+	}
+	if _, ok := got.Shortcuts["toggle"]; ok {
+		t.Error("removed shortcut toggle is still present")
+	}
+	if _, ok := got.ExtraHeaders["X-Remove"]; ok {
+		t.Error("removed extra header X-Remove is still present")
+	}
+	if got.ExtraHeaders["X-Keep"] != "1" {
+		t.Errorf("ExtraHeaders = %v, want X-Keep kept", got.ExtraHeaders)
+	}
+}
+
+func TestUpdateFromJSONKeepsMissingFields(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Profiles = []SolveProfile{{ID: "p1", Name: "编程"}}
+	cfg.ActiveProfile = "p1"
+	cm := newTestManager(t, cfg)
+
+	if err := cm.UpdateFromJSON(`{"model": "other-model"}`); err != nil {
+		t.Fatalf("UpdateFromJSON: %v", err)
+	}
+
+	got := cm.Get()
+	if got.Model != "other-model" {
+		t.Errorf("Model = %q, want other-model", got.Model)
+	}
+	if len(got.Profiles) != 1 || got.ActiveProfile != "p1" {
+		t.Errorf("profiles were not kept: %v, active %q", got.Profiles, got.ActiveProfile)
+	}
+	if len(got.Shortcuts) != len(cfg.Shortcuts) {
+		t.Errorf("Shortcuts changed without being sent: %d, want %d", len(got.Shortcuts), len(cfg.Shortcuts))
+	}
+}
+
+func TestUpdateFromJSONReplacesSliceElements(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Profiles = []SolveProfile{{ID: "p1", Name: "编程", Model: "strong-model"}}
+	cm := newTestManager(t, cfg)
+
+	if err := cm.UpdateFromJSON(`{"profiles": [{"id": "p1", "name": "编程"}]}`); err != nil {
+		t.Fatalf("UpdateFromJSON: %v", err)
+	}
+
+	if got := cm.Get().Profiles[0].Model; got != "" {
+		t.Errorf("profile model = %q, want cleared", got)
+	}
+}
+
+func TestUpdateFromJSONRejectsInvalid(t *testing.T) {
+	cm := newTestManager(t, NewDefaultConfig())
+
+	if err := cm.UpdateFromJSON(`{"opacity": 3}`); err == nil {
+		t.Fatal("UpdateFromJSON accepted an invalid opacity")
+	}
+	if got := cm.Get().Opacity; got != NewDefaultConfig().Opacity {
+		t.Errorf("Opacity = %v, invalid update must not be applied", got)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import "slices"
+
+// 题型（自动识别题型后路由到 Category 相同的解题方案）
+const (
+	CategoryCoding  = "coding"  // 编程题
+	CategoryMath    = "math"    // 数学计算与证明
+	CategoryChoice  = "choice"  // 选择题
+	CategoryProse   = "prose"   // 问答、简答与文字题
+	CategoryDiagram = "diagram" // 图表、架构图与流程图
+	CategoryOther   = "other"   // 其他
+)
+
+// Categories 所有题型
+var Categories = []string{CategoryCoding, CategoryMath, CategoryChoice, CategoryProse, CategoryDiagram, CategoryOther}
+
+// SolveProfile 解题方案：按题型（编程、数学、选择题、文档总结等）切换提示词、模型和参数
+// 未设置的字段沿用主配置，可在 Shortcuts 中以 "profile:<ID>" 绑定快捷键（仅 Windows）
+type SolveProfile struct {
+	ID                 string         `json:"id"`
+	Name               string         `json:"name"`
+	Prompt             string         `json:"prompt,omitempty"`
+	Model              string         `json:"model,omitempty"`
+	Temperature        *float64       `json:"temperature,omitempty"`
+	ThinkingBudget     *int           `json:"thinkingBudget,omitempty"`
+	CompressionQuality int            `json:"compressionQuality,omitempty"`
+	Sharpening         *float64       `json:"sharpening,omitempty"`
+	Grayscale          *bool          `json:"grayscale,omitempty"`
+	NoCompression      *bool          `json:"noCompression,omitempty"`
+	ScreenshotMode     string         `json:"screenshotMode,omitempty"`
+	ScreenshotRegion   *CaptureRegion `json:"screenshotRegion,omitempty"` // 区域截图模式下的截图区域，未设置时沿用主配置
+	AttachResume       *bool          `json:"attachResume,omitempty"`     // false 时不附带简历
+	Category           string         `json:"category,omitempty"`         // 开启自动路由时，识别为该题型的截图使用此方案
+}
+
+// FindProfile 按 ID 查找解题方案
+func (c *Config) FindProfile(id string) (SolveProfile, bool) {
+	for _, p := range c.Profiles {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return SolveProfile{}, false
+}
+
+// ProfileForCategory 查找处理指定题型的解题方案
+func (c *Config) ProfileForCategory(category string) (SolveProfile, bool) {
+	if category == "" {
+		return SolveProfile{}, false
+	}
+	for _, p := range c.Profiles {
+		if p.Category == category {
+			return p, true
+		}
+	}
+	return SolveProfile{}, false
+}
+
+// WithProfile 返回应用了解题方案后的配置副本，id 为空或不存在时返回原配置
+func (c Config) WithProfile(id string) Config {
+	if id == "" {
+		return c
+	}
+	p, ok := c.FindProfile(id)
+	if !ok {
+		return c
+	}
+
+	if p.Prompt != "" {
+		c.Prompt = p.Prompt
+	}
+	if p.Model != "" {
+		c.Model = p.Model
+	}
+	if p.Temperature != nil {
+		c.Temperature = *p.Temperature
+	}
+	if p.ThinkingBudget != nil {
+		c.ThinkingBudget = *p.ThinkingBudget
+	}
+	if p.CompressionQuality > 0 {
+		c.CompressionQuality = p.CompressionQuality
+	}
+	if p.Sharpening != nil {
+		c.Sharpening = *p.Sharpening
+	}
+	if p.Grayscale != nil {
+		c.Grayscale = *p.Grayscale
+	}
+	if p.NoCompression != nil {
+		c.NoCompression = *p.NoCompression
+	}
+	if p.ScreenshotMode != "" {
+		c.ScreenshotMode = p.ScreenshotMode
+	}
+	if p.ScreenshotRegion != nil {
+		region := *p.ScreenshotRegion
+		c.ScreenshotRegion = &region
+	}
+	if p.AttachResume != nil && !*p.AttachResume {
+		c.ResumePath = ""
+		c.ResumeBase64 = ""
+		c.ResumeContent = ""
+	}
+	return c
+}
+
+// validateProfiles 校验解题方案
+func (c *Config) validateProfiles() error {
+	seen := make(map[string]bool)
+	categories := make(map[string]bool)
+	for _, p := range c.Profiles {
+		if p.ID == "" || p.Name == "" {
+			return &ValidationError{Field: "profiles", Message: "解题方案的 ID 和名称不能为空"}
+		}
+		if seen[p.ID] {
+			return &ValidationError{Field: "profiles", Message: "解题方案 ID 重复: " + p.ID}
+		}
+		seen[p.ID] = true
+
+		if p.CompressionQuality < 0 || p.CompressionQuality > 100 {
+			return &ValidationError{Field: "profiles", Message: "压缩质量必须在 1-100 之间: " + p.Name}
+		}
+		region := p.ScreenshotRegion
+		if region == nil {
+			region = c.ScreenshotRegion
+		}
+		if err := validateScreenshotMode(p.ScreenshotMode, region, "profiles", ": "+p.Name); err != nil {
+			return err
+		}
+		if p.Category != "" {
+			if !slices.Contains(Categories, p.Category) {
+				return &ValidationError{Field: "profiles", Message: "不支持的题型: " + p.Category}
+			}
+			if categories[p.Category] {
+				return &ValidationError{Field: "profiles", Message: "多个解题方案对应同一题型: " + p.Category}
+			}
+			categories[p.Category] = true
+		}
+	}
+	if c.ActiveProfile != "" && !seen[c.ActiveProfile] {
+		return &ValidationError{Field: "activeProfile", Message: "当前解题方案不存在: " + c.ActiveProfile}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+// RefineAction 改进动作：不截图，针对当前对话的上一个回答追问
+// Prompt 中的 {{answer}} 替换为上一个回答，{{language}} 替换为首选编程语言
+// 可在 Shortcuts 中以 "refine:<ID>" 绑定快捷键（仅 Windows，macOS 只支持预设快捷键）
+type RefineAction struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+}
+
+// DefaultRefineActions 默认的改进动作
+func DefaultRefineActions() []RefineAction {
+	return []RefineAction{
+		{ID: "optimize", Name: "优化复杂度", Prompt: "请在上一个解法的基础上优化时间复杂度（必要时兼顾空间复杂度），说明优化思路并给出优化前后的复杂度，然后给出完整代码。"},
+		{ID: "explain", Name: "逐步讲解", Prompt: "请逐步讲解上一个回答：先说明整体思路，再按步骤解释每一步为什么这样做，最后用一个小例子演示执行过程。"},
+		{ID: "comment", Name: "添加注释", Prompt: "请为上一个回答中的代码添加清晰的注释，保持代码逻辑不变，输出完整代码。"},
+		{ID: "tests", Name: "编写测试", Prompt: "请为上一个回答中的代码编写测试用例（使用 {{language}} 常用的测试方式），覆盖正常输入、边界情况和异常输入。"},
+		{ID: "shorter", Name: "精简回答", Prompt: "请把上一个回答精简到最核心的内容，保留最终答案与关键步骤，删除冗余解释。"},
+	}
+}
+
+// Refines 返回生效的改进动作，未配置（nil）时使用默认动作
+// 用户删除全部动作后为空列表，此时不使用任何动作
+func (c *Config) Refines() []RefineAction {
+	if c.RefineActions == nil {
+		return DefaultRefineActions()
+	}
+	return c.RefineActions
+}
+
+// FindRefineAction 按 ID 查找改进动作
+func (c *Config) FindRefineAction(id string) (RefineAction, bool) {
+	for _, a := range c.Refines() {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return RefineAction{}, false
+}
+
+// validateRefineActions 校验改进动作
+func (c *Config) validateRefineActions() error {
+	seen := make(map[string]bool)
+	for _, a := range c.RefineActions {
+		if a.ID == "" || a.Name == "" || a.Prompt == "" {
+			return &ValidationError{Field: "refineActions", Message: "改进动作的 ID、名称和提示词不能为空"}
+		}
+		if seen[a.ID] {
+			return &ValidationError{Field: "refineActions", Message: "改进动作 ID 重复: " + a.ID}
+		}
+		seen[a.ID] = true
+	}
+	return nil
+}
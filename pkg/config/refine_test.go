@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestRefines(t *testing.T) {
+	custom := []RefineAction{{ID: "a", Name: "A", Prompt: "p"}}
+	tests := []struct {
+		name    string
+		actions []RefineAction
+		want    int
+	}{
+		{"nil uses defaults", nil, len(DefaultRefineActions())},
+		{"empty means none", []RefineAction{}, 0},
+		{"custom", custom, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{RefineActions: tt.actions}
+			if got := len(cfg.Refines()); got != tt.want {
+				t.Errorf("len(Refines()) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateFromJSONDeletesAllRefineActions(t *testing.T) {
+	cm := newTestManager(t, NewDefaultConfig())
+
+	if err := cm.UpdateFromJSON(`{"refineActions": []}`); err != nil {
+		t.Fatalf("UpdateFromJSON: %v", err)
+	}
+	cfg := cm.Get()
+	if got := cfg.Refines(); len(got) != 0 {
+		t.Errorf("Refines() = %v, want none after deleting all actions", got)
+	}
+	// 保存后重新读取时仍为空列表
+	clone, err := cfg.clone()
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if clone.RefineActions == nil {
+		t.Error("empty refine actions became nil after a JSON round trip")
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"image"
+	"slices"
+)
+
+// CaptureRegion 区域截图模式下的截图区域（屏幕坐标，单位像素）
+type CaptureRegion struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Rect 转换为 image.Rectangle，未设置时返回空矩形
+func (r *CaptureRegion) Rect() image.Rectangle {
+	if r == nil {
+		return image.Rectangle{}
+	}
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// SetCaptureRegion 设置截图区域并切换为区域截图模式，profileID 为空时设置主配置
+func (c *Config) SetCaptureRegion(profileID string, region CaptureRegion) error {
+	if region.Width <= 0 || region.Height <= 0 {
+		return &ValidationError{Field: "screenshotRegion", Message: "截图区域的宽高必须大于 0"}
+	}
+	if profileID == "" {
+		c.ScreenshotMode = "region"
+		c.ScreenshotRegion = &region
+		return nil
+	}
+	// 复制后再修改，避免影响共享同一底层数组的其他配置副本
+	c.Profiles = slices.Clone(c.Profiles)
+	for i := range c.Profiles {
+		if c.Profiles[i].ID == profileID {
+			c.Profiles[i].ScreenshotMode = "region"
+			c.Profiles[i].ScreenshotRegion = &region
+			return nil
+		}
+	}
+	return &ValidationError{Field: "profiles", Message: "解题方案不存在: " + profileID}
+}
+
+// validateScreenshotMode 校验截图模式，区域模式必须设置有效的区域
+func validateScreenshotMode(mode string, region *CaptureRegion, field, suffix string) error {
+	switch mode {
+	case "", "fullscreen", "window":
+		return nil
+	case "region":
+		if region == nil || region.Width <= 0 || region.Height <= 0 {
+			return &ValidationError{Field: field, Message: "区域截图模式需要设置宽高大于 0 的截图区域" + suffix}
+		}
+		return nil
+	default:
+		return &ValidationError{Field: field, Message: "截图模式必须是 'fullscreen'、'window' 或 'region'" + suffix}
+	}
+}
@@ -0,0 +1,288 @@
+package export
+
+import (
+	"Q-Solver/pkg/history"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 导出格式
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// 截图处理方式
+const (
+	ImagesEmbed   = "embed"   // 以 data URL 内嵌到文件中
+	ImagesSidecar = "sidecar" // 写入同名目录，文件中引用相对路径
+	ImagesNone    = "none"    // 不导出截图
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// Options 导出选项
+type Options struct {
+	Format          string `json:"format"`
+	Images          string `json:"images"`          // HTML 始终内嵌截图以保证单文件可用
+	IncludeThinking bool   `json:"includeThinking"` // 是否包含思考过程
+}
+
+// Transcript 导出用的对话记录（JSON 导出的结构）
+type Transcript struct {
+	ID        string           `json:"id"`
+	Kind      string           `json:"kind"`
+	Title     string           `json:"title"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Turns     []TranscriptTurn `json:"turns"`
+}
+
+// TranscriptTurn 一轮问答
+type TranscriptTurn struct {
+	Question    string    `json:"question,omitempty"`
+	Images      []string  `json:"images,omitempty"` // data URL 或相对导出文件的路径
+	Model       string    `json:"model,omitempty"`
+	Thinking    string    `json:"thinking,omitempty"`
+	Answer      string    `json:"answer"`
+	StartedAt   time.Time `json:"startedAt"`
+	DurationMs  int64     `json:"durationMs,omitempty"`
+	Interrupted bool      `json:"interrupted,omitempty"` // 回答被中断，只有部分内容
+}
+
+// Extension 返回格式对应的文件扩展名
+func Extension(format string) string {
+	switch format {
+	case FormatHTML:
+		return ".html"
+	case FormatJSON:
+		return ".json"
+	default:
+		return ".md"
+	}
+}
+
+// normalize 填充默认选项
+func (o Options) normalize() (Options, error) {
+	switch o.Format {
+	case "":
+		o.Format = FormatMarkdown
+	case FormatMarkdown, FormatHTML, FormatJSON:
+	default:
+		return o, fmt.Errorf("不支持的导出格式: %s", o.Format)
+	}
+	switch o.Images {
+	case "":
+		o.Images = ImagesEmbed
+	case ImagesEmbed, ImagesSidecar, ImagesNone:
+	default:
+		return o, fmt.Errorf("不支持的截图导出方式: %s", o.Images)
+	}
+	if o.Format == FormatHTML && o.Images == ImagesSidecar {
+		o.Images = ImagesEmbed
+	}
+	return o, nil
+}
+
+// WriteFile 将对话导出到 path，截图按选项内嵌或写入 path 同名的 _files 目录
+func WriteFile(conv *history.Conversation, path string, opts Options) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+
+	var images *sidecar
+	if opts.Images == ImagesSidecar {
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "_files"
+		images = &sidecar{dir: filepath.Join(filepath.Dir(path), base), rel: base}
+	}
+
+	t, err := buildTranscript(conv, opts, images)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch opts.Format {
+	case FormatHTML:
+		data = []byte(renderHTML(t))
+	case FormatJSON:
+		if data, err = json.MarshalIndent(t, "", "  "); err != nil {
+			return err
+		}
+	default:
+		data = []byte(renderMarkdown(t))
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// WriteDir 将多个对话分别导出到目录中，返回成功导出的数量
+// 文件名与本次导出或目录中已有的文件重复时追加序号，不覆盖已有文件
+func WriteDir(convs []*history.Conversation, dir string, opts Options) (int, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	used := make(map[string]bool, len(convs))
+	taken := func(name string) bool {
+		if used[name] {
+			return true
+		}
+		_, err := os.Lstat(filepath.Join(dir, name))
+		return err == nil
+	}
+	count := 0
+	for _, conv := range convs {
+		name := FileName(conv, opts.Format)
+		base, ext := strings.TrimSuffix(name, Extension(opts.Format)), Extension(opts.Format)
+		for i := 2; taken(name); i++ {
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		used[name] = true
+
+		if err := WriteFile(conv, filepath.Join(dir, name), opts); err != nil {
+			logger.Printf("[导出] 导出对话 %s 失败: %v", conv.ID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// FileName 生成对话的默认文件名（创建时间 + 标题）
+func FileName(conv *history.Conversation, format string) string {
+	title := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '\n', '\r', '\t':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(conv.Title))
+	if runes := []rune(title); len(runes) > 40 {
+		title = string(runes[:40])
+	}
+
+	name := "q-solver-" + conv.CreatedAt.Format("20060102-150405")
+	if title != "" {
+		name += "-" + title
+	}
+	return name + Extension(format)
+}
+
+// kindName 对话类型的显示名称
+func kindName(kind string) string {
+	if kind == history.KindLive {
+		return "实时对话"
+	}
+	return "解题"
+}
+
+// buildTranscript 将对话记录转换为导出结构，images 非 nil 时截图写入旁路目录
+func buildTranscript(conv *history.Conversation, opts Options, images *sidecar) (Transcript, error) {
+	kind := conv.Kind
+	if kind == history.KindSolve {
+		kind = "solve"
+	}
+	t := Transcript{
+		ID:        conv.ID,
+		Kind:      kind,
+		Title:     conv.Title,
+		CreatedAt: conv.CreatedAt,
+		UpdatedAt: conv.UpdatedAt,
+		Turns:     make([]TranscriptTurn, 0, len(conv.Turns)),
+	}
+
+	for i, turn := range conv.Turns {
+		tt := TranscriptTurn{
+			Model:       turn.Model,
+			Answer:      turn.Answer,
+			StartedAt:   turn.StartedAt,
+			DurationMs:  turn.DurationMs,
+			Interrupted: turn.Interrupted,
+		}
+		if opts.IncludeThinking {
+			tt.Thinking = turn.Thinking
+		}
+
+		var question []string
+		if turn.User.Content != "" {
+			question = append(question, turn.User.Content)
+		}
+		for j, part := range turn.User.Parts {
+			switch part.Type {
+			case llm.ContentText:
+				if text := strings.TrimSpace(part.Text); text != "" {
+					question = append(question, text)
+				}
+			case llm.ContentImage:
+				switch opts.Images {
+				case ImagesEmbed:
+					tt.Images = append(tt.Images, part.Base64)
+				case ImagesSidecar:
+					ref, err := images.write(part.Base64, fmt.Sprintf("turn%d-%d", i+1, j+1))
+					if err != nil {
+						return t, err
+					}
+					tt.Images = append(tt.Images, ref)
+				}
+			}
+		}
+		tt.Question = strings.Join(question, "\n\n")
+		t.Turns = append(t.Turns, tt)
+	}
+	return t, nil
+}
+
+// sidecar 截图旁路目录
+type sidecar struct {
+	dir string // 目录绝对路径
+	rel string // 相对导出文件的路径
+}
+
+// write 解码 data URL 写入图片文件，返回相对路径
+func (s *sidecar) write(dataURL string, name string) (string, error) {
+	mimeType, data := llm.ParseBase64DataURL(dataURL)
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("截图解码失败: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+
+	file := name + imageExtension(mimeType)
+	if err := os.WriteFile(filepath.Join(s.dir, file), raw, 0644); err != nil {
+		return "", err
+	}
+	return s.rel + "/" + file, nil
+}
+
+func imageExtension(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".jpg"
+	}
+}
+
+// formatDuration 以秒显示耗时
+func formatDuration(ms int64) string {
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
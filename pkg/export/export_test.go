@@ -0,0 +1,35 @@
+package export
+
+import (
+	"Q-Solver/pkg/history"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteDirKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	conv := history.NewConversation("")
+	conv.Title = "题目"
+	conv.CreatedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+
+	name := FileName(conv, FormatMarkdown)
+	existing := filepath.Join(dir, name)
+	if err := os.WriteFile(existing, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := WriteDir([]*history.Conversation{conv, conv}, dir, Options{Format: FormatMarkdown})
+	if err != nil || count != 2 {
+		t.Fatalf("WriteDir() = %d, %v, want 2, nil", count, err)
+	}
+
+	if data, _ := os.ReadFile(existing); string(data) != "keep" {
+		t.Errorf("existing file was overwritten: %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("dir has %d files, want 3", len(entries))
+	}
+}
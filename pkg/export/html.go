@@ -0,0 +1,230 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// htmlStyle 内联样式，保证导出的 HTML 单文件可直接打开
+const htmlStyle = `body{max-width:920px;margin:32px auto;padding:0 20px;font-family:-apple-system,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;line-height:1.7;color:#24292f}
+h1{border-bottom:1px solid #d0d7de;padding-bottom:8px}
+.meta{color:#57606a;font-size:14px}
+.turn{border-top:1px solid #d0d7de;margin-top:32px;padding-top:8px}
+.question{background:#f6f8fa;border-radius:6px;padding:12px 16px}
+.question img{display:block;max-width:100%;margin:8px 0;border:1px solid #d0d7de;border-radius:4px}
+details{color:#57606a;margin:12px 0}
+pre{background:#f6f8fa;border-radius:6px;padding:12px 16px;overflow:auto;line-height:1.45}
+code{font-family:ui-monospace,SFMono-Regular,Consolas,"Liberation Mono",monospace;font-size:90%}
+:not(pre)>code{background:#eff1f3;border-radius:4px;padding:2px 4px}
+blockquote{margin:0;padding:0 16px;color:#57606a;border-left:4px solid #d0d7de}
+table{border-collapse:collapse}
+th,td{border:1px solid #d0d7de;padding:6px 12px}`
+
+// renderHTML 渲染为自包含的 HTML 页面
+func renderHTML(t Transcript) string {
+	var b strings.Builder
+	title := html.EscapeString(titleOf(t))
+
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", title, htmlStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", title)
+	fmt.Fprintf(&b, "<p class=\"meta\">%s · 创建于 %s · 共 %d 轮</p>\n",
+		kindName(t.Kind), t.CreatedAt.Format(timeLayout), len(t.Turns))
+
+	for i, turn := range t.Turns {
+		b.WriteString("<section class=\"turn\">\n")
+		fmt.Fprintf(&b, "<h2>第 %d 轮</h2>\n", i+1)
+		if meta := turnMeta(turn); meta != "" {
+			fmt.Fprintf(&b, "<p class=\"meta\">%s</p>\n", html.EscapeString(meta))
+		}
+
+		if turn.Question != "" || len(turn.Images) > 0 {
+			b.WriteString("<div class=\"question\">\n")
+			if turn.Question != "" {
+				b.WriteString(markdownToHTML(turn.Question))
+			}
+			for j, img := range turn.Images {
+				fmt.Fprintf(&b, "<img src=\"%s\" alt=\"截图 %d\">\n", html.EscapeString(img), j+1)
+			}
+			b.WriteString("</div>\n")
+		}
+
+		if turn.Thinking != "" {
+			b.WriteString("<details>\n<summary>思考过程</summary>\n")
+			b.WriteString(markdownToHTML(turn.Thinking))
+			b.WriteString("</details>\n")
+		}
+
+		b.WriteString("<div class=\"answer\">\n")
+		b.WriteString(markdownToHTML(turn.Answer))
+		b.WriteString("</div>\n</section>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	ulPattern       = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	olPattern       = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	hrPattern       = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	tableSepPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	boldPattern     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	linkPattern     = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	fenceMarkers    = []string{"```", "~~~"}
+)
+
+// markdownToHTML 将回答中常见的 Markdown 语法转换为 HTML
+// 只覆盖模型回答常用的子集：代码块、标题、列表、引用、表格、分隔线、行内代码、粗体与链接
+func markdownToHTML(md string) string {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	var para []string
+	listTag := ""
+
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if listTag != "" {
+			b.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+	openList := func(tag string) {
+		flushPara()
+		if listTag != tag {
+			closeList()
+			b.WriteString("<" + tag + ">\n")
+			listTag = tag
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// 代码块：内容原样转义输出，未闭合时延续到末尾
+		if fence := fenceOf(trimmed); fence != "" {
+			flushPara()
+			closeList()
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			if lang != "" {
+				fmt.Fprintf(&b, "<pre><code class=\"language-%s\">", html.EscapeString(strings.Fields(lang)[0]))
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+			closeList()
+		case hrPattern.MatchString(trimmed):
+			flushPara()
+			closeList()
+			b.WriteString("<hr>\n")
+		case headingPattern.MatchString(trimmed):
+			flushPara()
+			closeList()
+			m := headingPattern.FindStringSubmatch(trimmed)
+			level := len(m[1])
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, inlineHTML(m[2]), level)
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			closeList()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + markdownToHTML(strings.Join(quote, "\n")) + "</blockquote>\n")
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && tableSepPattern.MatchString(lines[i+1]):
+			flushPara()
+			closeList()
+			b.WriteString("<table>\n<thead><tr>")
+			for _, cell := range tableCells(trimmed) {
+				b.WriteString("<th>" + inlineHTML(cell) + "</th>")
+			}
+			b.WriteString("</tr></thead>\n<tbody>\n")
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				b.WriteString("<tr>")
+				for _, cell := range tableCells(strings.TrimSpace(lines[i])) {
+					b.WriteString("<td>" + inlineHTML(cell) + "</td>")
+				}
+				b.WriteString("</tr>\n")
+			}
+			i--
+			b.WriteString("</tbody>\n</table>\n")
+		case ulPattern.MatchString(line):
+			openList("ul")
+			b.WriteString("<li>" + inlineHTML(ulPattern.FindStringSubmatch(line)[1]) + "</li>\n")
+		case olPattern.MatchString(line):
+			openList("ol")
+			b.WriteString("<li>" + inlineHTML(olPattern.FindStringSubmatch(line)[1]) + "</li>\n")
+		default:
+			closeList()
+			para = append(para, inlineHTML(trimmed))
+		}
+	}
+	flushPara()
+	closeList()
+	return b.String()
+}
+
+// fenceOf 返回代码块起始标记，不是代码块时返回空
+func fenceOf(line string) string {
+	for _, fence := range fenceMarkers {
+		if strings.HasPrefix(line, fence) {
+			return fence
+		}
+	}
+	return ""
+}
+
+// tableCells 拆分表格行的单元格
+func tableCells(line string) []string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// inlineHTML 转换行内语法，行内代码中的内容不再做其他转换
+func inlineHTML(text string) string {
+	parts := strings.Split(text, "`")
+	var b strings.Builder
+	for i, part := range parts {
+		escaped := html.EscapeString(part)
+		// 奇数段位于成对的反引号之间；末尾落单的反引号按普通文本处理
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString("<code>" + escaped + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+		escaped = boldPattern.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = linkPattern.ReplaceAllString(escaped, `<a href="$2">$1</a>`)
+		b.WriteString(escaped)
+	}
+	return b.String()
+}
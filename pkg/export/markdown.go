@@ -0,0 +1,74 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderMarkdown 渲染为 Markdown，回答原样保留（包括代码块）
+func renderMarkdown(t Transcript) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", titleOf(t))
+	fmt.Fprintf(&b, "- 类型：%s\n", kindName(t.Kind))
+	fmt.Fprintf(&b, "- 创建时间：%s\n", t.CreatedAt.Format(timeLayout))
+	fmt.Fprintf(&b, "- 轮数：%d\n", len(t.Turns))
+
+	for i, turn := range t.Turns {
+		fmt.Fprintf(&b, "\n---\n\n## 第 %d 轮\n\n", i+1)
+		if meta := turnMeta(turn); meta != "" {
+			fmt.Fprintf(&b, "> %s\n\n", meta)
+		}
+
+		if turn.Question != "" || len(turn.Images) > 0 {
+			b.WriteString("### 问题\n\n")
+			if turn.Question != "" {
+				b.WriteString(turn.Question)
+				b.WriteString("\n\n")
+			}
+			for j, img := range turn.Images {
+				fmt.Fprintf(&b, "![截图 %d](%s)\n\n", j+1, img)
+			}
+		}
+
+		if turn.Thinking != "" {
+			b.WriteString("### 思考过程\n\n")
+			for _, line := range strings.Split(strings.TrimSpace(turn.Thinking), "\n") {
+				b.WriteString(strings.TrimRight("> "+line, " "))
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString("### 回答\n\n")
+		b.WriteString(strings.TrimSpace(turn.Answer))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// titleOf 对话标题，为空时使用默认标题
+func titleOf(t Transcript) string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return "Q-Solver " + kindName(t.Kind) + "记录"
+}
+
+// turnMeta 单轮的模型、时间与耗时说明
+func turnMeta(turn TranscriptTurn) string {
+	var meta []string
+	if turn.Model != "" {
+		meta = append(meta, "模型："+turn.Model)
+	}
+	if !turn.StartedAt.IsZero() {
+		meta = append(meta, turn.StartedAt.Format(timeLayout))
+	}
+	if turn.DurationMs > 0 {
+		meta = append(meta, "耗时 "+formatDuration(turn.DurationMs))
+	}
+	if turn.Interrupted {
+		meta = append(meta, "已中断")
+	}
+	return strings.Join(meta, " · ")
+}
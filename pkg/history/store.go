@@ -0,0 +1,584 @@
+package history
+
+import (
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// fileExt 对话文件扩展名
+const fileExt = ".json"
+
+// indexFile 摘要索引文件名（扩展名不同于对话文件，不会被当作对话读取）
+const indexFile = "index.idx"
+
+// 标题与摘要长度（字符数）
+const (
+	titleRunes   = 40
+	previewRunes = 120
+	snippetRunes = 60
+)
+
+// ErrNotFound 对话不存在
+var ErrNotFound = errors.New("对话记录不存在")
+
+// Turn 一轮问答
+type Turn struct {
+	User         llm.Message `json:"user"` // 用户消息（截图等，不含简历附件）
+	Model        string      `json:"model"`
+	Thinking     string      `json:"thinking,omitempty"`
+	Answer       string      `json:"answer"`
+	Usage        *llm.Usage  `json:"usage,omitempty"`
+	StartedAt    time.Time   `json:"startedAt"`
+	DurationMs   int64       `json:"durationMs"`
+	FirstChunkMs int64       `json:"firstChunkMs,omitempty"`
+	Routing      *Routing    `json:"routing,omitempty"`     // 自动路由的识别结果
+	Interrupted  bool        `json:"interrupted,omitempty"` // 任务被取消，Answer 与 Thinking 只是已输出的部分
+}
+
+// Routing 自动识别题型与选择解题方案的结果，用于审计和调整路由
+type Routing struct {
+	Category    string  `json:"category"`
+	Confidence  float64 `json:"confidence"`
+	Reason      string  `json:"reason,omitempty"`
+	Model       string  `json:"model"`               // 识别使用的辅助模型
+	ProfileID   string  `json:"profileId,omitempty"` // 路由到的解题方案，为空表示沿用当前方案
+	ProfileName string  `json:"profileName,omitempty"`
+	DurationMs  int64   `json:"durationMs"`
+}
+
+// 对话类型
+const (
+	KindSolve = ""     // 截图解题
+	KindLive  = "live" // Live 实时对话
+)
+
+// Conversation 一次完整的解题对话
+type Conversation struct {
+	ID           string    `json:"id"`
+	Kind         string    `json:"kind,omitempty"`
+	Title        string    `json:"title"`
+	SystemPrompt string    `json:"systemPrompt,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	Turns        []Turn    `json:"turns"`
+}
+
+// Summary 对话摘要（列表与搜索结果，不含截图）
+type Summary struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind,omitempty"`
+	Title     string    `json:"title"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	TurnCount int       `json:"turnCount"`
+	Preview   string    `json:"preview"`
+	Snippet   string    `json:"snippet,omitempty"` // 搜索命中的上下文
+}
+
+// NewConversation 创建新对话
+func NewConversation(systemPrompt string) *Conversation {
+	now := time.Now()
+	return &Conversation{
+		ID:           fmt.Sprintf("%d", now.UnixNano()),
+		SystemPrompt: systemPrompt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Turns:        make([]Turn, 0),
+	}
+}
+
+// ResumeNotice 注入简历附件时附带的说明，紧随其后的 PDF 即为简历
+const ResumeNotice = "\n\n# 候选人简历已作为附件发送，请参考简历内容回答。"
+
+// ImageOmitted 关闭保存截图时代替截图与图片附件写入记录的文字
+const ImageOmitted = "[截图未保存]"
+
+// StripResume 去掉注入的简历说明与简历附件，保留用户自己添加的文件
+func StripResume(parts []llm.ContentPart) []llm.ContentPart {
+	result := make([]llm.ContentPart, 0, len(parts))
+	for i := 0; i < len(parts); i++ {
+		if parts[i].Type == llm.ContentText && parts[i].Text == ResumeNotice {
+			if i+1 < len(parts) && parts[i+1].Type == llm.ContentPDF {
+				i++
+			}
+			continue
+		}
+		result = append(result, parts[i])
+	}
+	return result
+}
+
+// AddTurn 追加一轮问答，首轮回答作为标题
+func (c *Conversation) AddTurn(turn Turn) {
+	// 简历附件体积大且每轮都会重新注入，不做持久化
+	turn.User.Parts = StripResume(turn.User.Parts)
+
+	c.Turns = append(c.Turns, turn)
+	c.UpdatedAt = time.Now()
+	if c.Title == "" {
+		c.Title = makeTitle(turn)
+	}
+}
+
+// Fork 复制出一条新对话，保留已有的问答，之后各自独立保存
+func (c *Conversation) Fork() *Conversation {
+	fork := NewConversation(c.SystemPrompt)
+	fork.Kind = c.Kind
+	fork.Title = c.Title
+	fork.Turns = append(fork.Turns, c.Turns...)
+	return fork
+}
+
+// Messages 还原为可继续对话的消息列表
+func (c *Conversation) Messages() []llm.Message {
+	messages := make([]llm.Message, 0, len(c.Turns)*2+1)
+	if c.SystemPrompt != "" {
+		messages = append(messages, llm.NewSystemMessage(c.SystemPrompt))
+	}
+	for _, turn := range c.Turns {
+		messages = append(messages, turn.User)
+		messages = append(messages, llm.NewAssistantMessage(turn.Answer))
+	}
+	return messages
+}
+
+// Summary 生成对话摘要
+func (c *Conversation) Summary() Summary {
+	s := Summary{
+		ID:        c.ID,
+		Kind:      c.Kind,
+		Title:     c.Title,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		TurnCount: len(c.Turns),
+	}
+	if n := len(c.Turns); n > 0 {
+		last := c.Turns[n-1]
+		s.Model = last.Model
+		s.Preview = truncate(collapse(last.Answer), previewRunes)
+	}
+	return s
+}
+
+// Store 对话记录的本地存储（每个对话一个 JSON 文件）
+// 列表与搜索使用摘要索引，只在对话文件变化时重新解析该文件
+type Store struct {
+	dir string
+	mu  sync.Mutex
+
+	index      map[string]*indexEntry // 按对话 ID 索引，首次列表或搜索时加载
+	indexDirty bool                   // 内存中的索引尚未写回磁盘
+
+	dropImages atomic.Bool // 保存时以文字代替截图与图片附件
+}
+
+// indexEntry 一条对话的摘要与可搜索文字（不含截图），按文件修改时间和大小判断是否过期
+type indexEntry struct {
+	Summary Summary  `json:"summary"`
+	Fields  []string `json:"fields"`
+	ModTime int64    `json:"modTime"`
+	Size    int64    `json:"size"`
+}
+
+// NewStore 创建对话记录存储
+func NewStore(dir string) *Store {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Printf("创建历史记录目录失败: %v", err)
+	}
+	return &Store{dir: dir}
+}
+
+// SetSaveImages 设置是否在记录中保存截图与图片附件，关闭后只影响之后的保存
+func (s *Store) SetSaveImages(save bool) {
+	s.dropImages.Store(!save)
+}
+
+// Save 保存对话（先写临时文件再重命名，避免写入中断导致文件损坏）
+func (s *Store) Save(conv *Conversation) error {
+	path, err := s.path(conv.ID)
+	if err != nil {
+		return err
+	}
+	if s.dropImages.Load() {
+		conv = withoutImages(conv)
+	}
+	data, err := json.Marshal(conv)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writeFile(path, data); err != nil {
+		return err
+	}
+	if s.index != nil {
+		if info, err := os.Stat(path); err == nil {
+			s.index[conv.ID] = newIndexEntry(conv, info)
+			s.indexDirty = true
+		}
+	}
+	return nil
+}
+
+// writeFile 先写临时文件再重命名，调用方需持有锁
+func (s *Store) writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get 读取完整对话
+func (s *Store) Get(id string) (*Conversation, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return readConversation(path)
+}
+
+// Delete 删除对话
+func (s *Store) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if s.index != nil {
+		delete(s.index, id)
+		s.indexDirty = true
+	}
+	return nil
+}
+
+// List 列出所有对话摘要（最近更新的在前）
+func (s *Store) List() ([]Summary, error) {
+	return s.Search("")
+}
+
+// Search 全文搜索标题、问题文本、思考过程和回答（不区分大小写），query 为空时返回全部
+func (s *Store) Search(query string) ([]Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshIndex(); err != nil {
+		if os.IsNotExist(err) {
+			return []Summary{}, nil
+		}
+		return nil, err
+	}
+
+	query = strings.TrimSpace(query)
+	results := make([]Summary, 0, len(s.index))
+	for _, entry := range s.index {
+		summary := entry.Summary
+		if query != "" {
+			snippet, ok := match(entry.Fields, query)
+			if !ok {
+				continue
+			}
+			summary.Snippet = snippet
+		}
+		results = append(results, summary)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].UpdatedAt.After(results[j].UpdatedAt)
+	})
+	return results, nil
+}
+
+// Range 读取创建时间在 [from, to) 内的完整对话（按创建时间先后排列），零值表示不限
+func (s *Store) Range(from, to time.Time) ([]*Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Conversation{}, nil
+		}
+		return nil, err
+	}
+
+	results := make([]*Conversation, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != fileExt {
+			continue
+		}
+		conv, err := readConversation(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			logger.Printf("[历史] 跳过无法读取的记录 %s: %v", entry.Name(), err)
+			continue
+		}
+		if (!from.IsZero() && conv.CreatedAt.Before(from)) || (!to.IsZero() && !conv.CreatedAt.Before(to)) {
+			continue
+		}
+		results = append(results, conv)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	return results, nil
+}
+
+// refreshIndex 加载摘要索引，并重新解析新增或修改过的对话文件，调用方需持有锁
+func (s *Store) refreshIndex() error {
+	if s.index == nil {
+		s.index = s.readIndex()
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != fileExt {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), fileExt)
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		seen[id] = true
+		if cached, ok := s.index[id]; ok && cached.ModTime == info.ModTime().UnixNano() && cached.Size == info.Size() {
+			continue
+		}
+
+		conv, err := readConversation(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			logger.Printf("[历史] 跳过无法读取的记录 %s: %v", entry.Name(), err)
+			continue
+		}
+		s.index[id] = newIndexEntry(conv, info)
+		s.indexDirty = true
+	}
+	for id := range s.index {
+		if !seen[id] {
+			delete(s.index, id)
+			s.indexDirty = true
+		}
+	}
+
+	if s.indexDirty {
+		if err := s.writeIndex(); err != nil {
+			logger.Printf("[历史] 保存摘要索引失败: %v", err)
+		} else {
+			s.indexDirty = false
+		}
+	}
+	return nil
+}
+
+// readIndex 读取磁盘上的摘要索引，不存在或损坏时返回空索引
+func (s *Store) readIndex() map[string]*indexEntry {
+	index := make(map[string]*indexEntry)
+	data, err := os.ReadFile(filepath.Join(s.dir, indexFile))
+	if err != nil {
+		return index
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		logger.Printf("[历史] 摘要索引损坏，将重新生成: %v", err)
+		return make(map[string]*indexEntry)
+	}
+	return index
+}
+
+// writeIndex 将摘要索引写回磁盘，调用方需持有锁
+func (s *Store) writeIndex() error {
+	data, err := json.Marshal(s.index)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(filepath.Join(s.dir, indexFile), data)
+}
+
+// newIndexEntry 生成对话的索引条目
+func newIndexEntry(conv *Conversation, info os.FileInfo) *indexEntry {
+	return &indexEntry{
+		Summary: conv.Summary(),
+		Fields:  searchFields(conv),
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+	}
+}
+
+// withoutImages 返回以文字代替截图与图片附件的对话副本，不修改原对话
+func withoutImages(conv *Conversation) *Conversation {
+	copied := *conv
+	copied.Turns = slices.Clone(conv.Turns)
+	for i, turn := range copied.Turns {
+		if !slices.ContainsFunc(turn.User.Parts, func(p llm.ContentPart) bool { return p.Type == llm.ContentImage }) {
+			continue
+		}
+		parts := make([]llm.ContentPart, 0, len(turn.User.Parts))
+		for _, part := range turn.User.Parts {
+			if part.Type == llm.ContentImage {
+				part = llm.TextPart(ImageOmitted)
+			}
+			parts = append(parts, part)
+		}
+		copied.Turns[i].User.Parts = parts
+	}
+	return &copied
+}
+
+// path 返回对话文件路径，拒绝包含路径分隔符的 ID
+func (s *Store) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("无效的对话 ID: %q", id)
+	}
+	return filepath.Join(s.dir, id+fileExt), nil
+}
+
+// readConversation 读取并解析对话文件
+func readConversation(path string) (*Conversation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var conv Conversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// searchFields 提取对话中可搜索的文字：标题、问题文本、回答和思考过程
+func searchFields(conv *Conversation) []string {
+	fields := []string{conv.Title}
+	for _, turn := range conv.Turns {
+		fields = append(fields, turn.User.Content)
+		for _, part := range turn.User.Parts {
+			if part.Type == llm.ContentText && part.Text != ImageOmitted {
+				fields = append(fields, part.Text)
+			}
+		}
+		fields = append(fields, turn.Answer, turn.Thinking)
+	}
+	return fields
+}
+
+// match 在可搜索的文字中查找关键字，返回命中处的上下文片段
+func match(fields []string, query string) (string, bool) {
+	for _, field := range fields {
+		if snippet, ok := snippetOf(field, query); ok {
+			return snippet, true
+		}
+	}
+	return "", false
+}
+
+// snippetOf 截取关键字前后的文本
+func snippetOf(text string, query string) (string, bool) {
+	runes := []rune(collapse(text))
+	lower := lowerRunes(runes)
+	queryRunes := lowerRunes([]rune(query))
+
+	idx := indexRunes(lower, queryRunes)
+	if idx < 0 {
+		return "", false
+	}
+
+	start := max(idx-snippetRunes/2, 0)
+	end := min(idx+len(queryRunes)+snippetRunes/2, len(runes))
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet, true
+}
+
+// lowerRunes 逐字符转小写，保证与原文下标一一对应
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// indexRunes 在 rune 切片中查找子串，逐位置直接比较，不为每个位置分配字符串
+func indexRunes(s []rune, sub []rune) int {
+	if len(sub) == 0 {
+		return 0
+	}
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if s[i] == sub[0] && slices.Equal(s[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// makeTitle 使用回答首个非空行作为标题
+func makeTitle(turn Turn) string {
+	for _, line := range strings.Split(turn.Answer, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#>*-` "))
+		if line != "" {
+			return truncate(line, titleRunes)
+		}
+	}
+	return turn.StartedAt.Format("2006-01-02 15:04")
+}
+
+// collapse 合并空白字符为单个空格
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate 按字符数截断
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,243 @@
+package history
+
+import (
+	"Q-Solver/pkg/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveConversation 保存一条单轮对话，updated 决定列表顺序
+func saveConversation(t *testing.T, s *Store, id, question, thinking, answer string, updated time.Time) *Conversation {
+	t.Helper()
+	conv := NewConversation("")
+	conv.ID = id
+	conv.AddTurn(Turn{
+		User:     llm.NewMultiPartMessage(llm.RoleUser, []llm.ContentPart{llm.ImagePart("data:image/png;base64,AAAA"), llm.TextPart(question)}),
+		Thinking: thinking,
+		Answer:   answer,
+	})
+	conv.UpdatedAt = updated
+	if err := s.Save(conv); err != nil {
+		t.Fatalf("Save(%s): %v", id, err)
+	}
+	return conv
+}
+
+func newSearchStore(t *testing.T) *Store {
+	t.Helper()
+	s := NewStore(t.TempDir())
+	now := time.Now()
+	saveConversation(t, s, "1", "两数之和", "用哈希表", "# Two Sum\n使用 HashMap 一次遍历", now.Add(-2*time.Hour))
+	saveConversation(t, s, "2", "反转链表", "迭代即可", "# Reverse List\n双指针", now.Add(-time.Hour))
+	saveConversation(t, s, "3", "LRU 缓存", "哈希表加双向链表", "# LRU Cache\n"+strings.Repeat("细节 ", 50)+"O(1) 完成", now)
+	return s
+}
+
+func ids(summaries []Summary) string {
+	var b strings.Builder
+	for _, s := range summaries {
+		b.WriteString(s.ID)
+	}
+	return b.String()
+}
+
+func TestStoreSearch(t *testing.T) {
+	s := newSearchStore(t)
+
+	tests := []struct {
+		name        string
+		query       string
+		want        string // 按更新时间倒序的 ID
+		wantSnippet string // 第一个结果的片段需包含的文字
+	}{
+		{"empty returns all", "", "321", ""},
+		{"blank returns all", "   ", "321", ""},
+		{"title", "Reverse", "2", "Reverse"},
+		{"case insensitive", "hashmap", "1", "HashMap"},
+		{"question text", "两数", "1", "两数之和"},
+		{"thinking", "哈希表", "31", "哈希表"},
+		{"answer", "双指针", "2", "双指针"},
+		{"no match", "红黑树", "", ""},
+		{"image data is not searchable", "AAAA", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Search(tt.query)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if ids(got) != tt.want {
+				t.Errorf("Search(%q) = %s, want %s", tt.query, ids(got), tt.want)
+			}
+			if tt.wantSnippet != "" && len(got) > 0 && !strings.Contains(got[0].Snippet, tt.wantSnippet) {
+				t.Errorf("Snippet = %q, want it to contain %q", got[0].Snippet, tt.wantSnippet)
+			}
+			if tt.query == "" && len(got) > 0 && got[0].Snippet != "" {
+				t.Errorf("Snippet = %q, want empty for list", got[0].Snippet)
+			}
+		})
+	}
+}
+
+func TestStoreSearchSnippet(t *testing.T) {
+	s := newSearchStore(t)
+
+	got, err := s.Search("o(1)")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Search = %v, %v, want one result", got, err)
+	}
+	snippet := got[0].Snippet
+	if !strings.HasPrefix(snippet, "…") || !strings.Contains(snippet, "O(1)") {
+		t.Errorf("Snippet = %q, want leading ellipsis and the match", snippet)
+	}
+	if n := len([]rune(snippet)); n > snippetRunes+len("o(1)")+2 {
+		t.Errorf("Snippet has %d runes, too long", n)
+	}
+}
+
+func TestStoreIndex(t *testing.T) {
+	s := newSearchStore(t)
+	if _, err := s.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	indexPath := filepath.Join(s.dir, indexFile)
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("index file not written: %v", err)
+	}
+
+	t.Run("reused by a new store", func(t *testing.T) {
+		// 篡改索引中的标题：文件未变化时应直接使用索引而不重新解析
+		reopened := NewStore(s.dir)
+		reopened.index = reopened.readIndex()
+		reopened.index["1"].Summary.Title = "from index"
+		list, err := reopened.List()
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if list[2].Title != "from index" {
+			t.Errorf("Title = %q, want the cached index entry", list[2].Title)
+		}
+	})
+
+	t.Run("refreshed after external change", func(t *testing.T) {
+		conv, err := s.Get("2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		conv.Turns[0].Answer = "改为递归实现"
+		if err := s.Save(conv); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := NewStore(s.dir).Search("递归")
+		if ids(got) != "2" {
+			t.Errorf("Search after change = %s, want 2", ids(got))
+		}
+	})
+
+	t.Run("drops removed files", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(s.dir, "1"+fileExt)); err != nil {
+			t.Fatal(err)
+		}
+		list, _ := NewStore(s.dir).List()
+		if ids(list) != "32" {
+			t.Errorf("List after removal = %s, want 32", ids(list))
+		}
+	})
+
+	t.Run("rebuilt when corrupted", func(t *testing.T) {
+		if err := os.WriteFile(indexPath, []byte("{broken"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		list, err := NewStore(s.dir).List()
+		if err != nil || ids(list) != "32" {
+			t.Errorf("List with corrupted index = %s, %v, want 32", ids(list), err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := s.Delete("3"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Delete("3"); err != ErrNotFound {
+			t.Errorf("second Delete = %v, want ErrNotFound", err)
+		}
+		list, _ := s.List()
+		if ids(list) != "2" {
+			t.Errorf("List after Delete = %s, want 2", ids(list))
+		}
+	})
+}
+
+func TestIndexRunes(t *testing.T) {
+	tests := []struct {
+		s, sub string
+		want   int
+	}{
+		{"hello", "", 0},
+		{"hello", "llo", 2},
+		{"hello", "hello!", -1},
+		{"两数之和", "之和", 2},
+		{"aaab", "aab", 1},
+		{"", "a", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s+"/"+tt.sub, func(t *testing.T) {
+			if got := indexRunes([]rune(tt.s), []rune(tt.sub)); got != tt.want {
+				t.Errorf("indexRunes(%q, %q) = %d, want %d", tt.s, tt.sub, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorePathRejectsTraversal(t *testing.T) {
+	s := NewStore(t.TempDir())
+	for _, id := range []string{"", "../x", "a/b", ".hidden"} {
+		if _, err := s.Get(id); err == nil || err == ErrNotFound {
+			t.Errorf("Get(%q) = %v, want invalid ID error", id, err)
+		}
+	}
+}
+
+func TestStoreSaveImages(t *testing.T) {
+	tests := []struct {
+		name       string
+		save       bool
+		wantImages int
+	}{
+		{"save screenshots", true, 1},
+		{"text only", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(t.TempDir())
+			s.SetSaveImages(tt.save)
+			conv := saveConversation(t, s, "1", "题目", "", "回答", time.Now())
+
+			got, err := s.Get("1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			images, placeholders := 0, 0
+			for _, part := range got.Turns[0].User.Parts {
+				switch {
+				case part.Type == llm.ContentImage:
+					images++
+				case part.Text == ImageOmitted:
+					placeholders++
+				}
+			}
+			if images != tt.wantImages || images+placeholders != 1 {
+				t.Errorf("saved %d images and %d placeholders, want %d images", images, placeholders, tt.wantImages)
+			}
+			if conv.Turns[0].User.Parts[0].Type != llm.ContentImage {
+				t.Error("Save modified the caller's conversation")
+			}
+			if list, _ := s.Search("未保存"); len(list) != 0 {
+				t.Error("placeholder text is searchable")
+			}
+		})
+	}
+}
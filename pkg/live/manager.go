@@ -2,7 +2,9 @@ package live
 
 import (
 	"Q-Solver/pkg/audio"
+	"Q-Solver/pkg/common"
 	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/history"
 	"Q-Solver/pkg/llm"
 	"Q-Solver/pkg/logger"
 	"Q-Solver/pkg/screen"
@@ -53,8 +55,14 @@ type LiveSessionManager struct {
 	// 当前轮次的问题和回答（用于累积后推送给 Graph）
 	currentQuestion strings.Builder
 	currentAnswer   strings.Builder
+	currentImages   []llm.ContentPart // 本轮模型请求的截图
+	roundStart      time.Time
 	roundMu         sync.Mutex
 
+	// 会话记录（停止后保留，供导出）
+	store      *history.Store
+	transcript *history.Conversation
+
 	// 重连状态机
 	state       atomic.Int32 // 当前状态 (SessionState)
 	reconnectMu sync.Mutex   // 保证只有一个协程执行重连
@@ -83,6 +91,25 @@ func NewLiveSessionManager(
 	}
 }
 
+// SetHistoryStore 设置会话记录存储，为 nil 时只在内存中保留最近一次会话
+func (m *LiveSessionManager) SetHistoryStore(store *history.Store) {
+	m.roundMu.Lock()
+	defer m.roundMu.Unlock()
+	m.store = store
+}
+
+// Transcript 返回最近一次会话的记录，尚未开始过会话时为 nil
+func (m *LiveSessionManager) Transcript() *history.Conversation {
+	m.roundMu.Lock()
+	defer m.roundMu.Unlock()
+	if m.transcript == nil {
+		return nil
+	}
+	conv := *m.transcript
+	conv.Turns = append([]history.Turn(nil), m.transcript.Turns...)
+	return &conv
+}
+
 // Start 启动 Live API 会话
 func (m *LiveSessionManager) Start() error {
 	m.mu.Lock()
@@ -110,6 +137,13 @@ func (m *LiveSessionManager) Start() error {
 
 	m.emitEvent("live:status", "connected")
 
+	// 开始新的会话记录
+	m.roundMu.Lock()
+	m.transcript = history.NewConversation(cfg.Prompt)
+	m.transcript.Kind = history.KindLive
+	m.transcript.Title = "实时对话 " + m.transcript.CreatedAt.Format("2006-01-02 15:04")
+	m.roundMu.Unlock()
+
 	// 保存 session (atomic)
 	m.session.Store(&session)
 	m.state.Store(int32(StateNormal))
@@ -182,6 +216,7 @@ func (m *LiveSessionManager) Stop() {
 	m.roundMu.Lock()
 	m.currentQuestion.Reset()
 	m.currentAnswer.Reset()
+	m.currentImages = nil
 	m.roundMu.Unlock()
 
 	// 执行清理
@@ -314,6 +349,11 @@ func (m *LiveSessionManager) receiveLoop() {
 
 	logger.Println("[receiveLoop] Live: 接收循环已启动")
 
+	// 合并转写与回答的流式片段，退出时发送剩余内容
+	interval := time.Duration(m.configManager.Get().StreamFlushInterval) * time.Millisecond
+	stream := common.NewStreamEmitter(m.emitEvent, interval, 0)
+	defer stream.Close()
+
 	for {
 		// 检查是否已取消
 		select {
@@ -381,26 +421,30 @@ func (m *LiveSessionManager) receiveLoop() {
 
 		case llm.LiveInterrupted:
 			logger.Println("[receiveLoop] 检测到打断")
-			m.emitEvent("live:Interrupted", msg.Text)
+			stream.Emit("live:Interrupted", msg.Text)
 			// 打断时，清空当前轮次缓存
 			m.roundMu.Lock()
 			m.currentQuestion.Reset()
 			m.currentAnswer.Reset()
+			m.currentImages = nil
 			m.roundMu.Unlock()
 
 		case llm.LiveMsgTranscript:
-			m.emitEvent("live:transcript", msg.Text)
+			stream.Append("live:transcript", msg.Text)
 			// 累积问题文本
 			m.roundMu.Lock()
+			if m.currentQuestion.Len() == 0 {
+				m.roundStart = time.Now()
+			}
 			m.currentQuestion.WriteString(msg.Text)
 			m.roundMu.Unlock()
 
 		case llm.LiveMsgInterviewerDone:
 			logger.Println("[receiveLoop] Live: 面试官说话结束")
-			m.emitEvent("live:interviewer-done")
+			stream.Emit("live:interviewer-done")
 
 		case llm.LiveMsgAIText:
-			m.emitEvent("live:ai-text", msg.Text)
+			stream.Append("live:ai-text", msg.Text)
 			// 累积回答文本
 			m.roundMu.Lock()
 			m.currentAnswer.WriteString(msg.Text)
@@ -414,15 +458,20 @@ func (m *LiveSessionManager) receiveLoop() {
 
 		case llm.LiveMsgDone:
 			logger.Println("[receiveLoop] Live: 对话轮完成")
-			m.emitEvent("live:done")
+			stream.Emit("live:done")
 			// 一轮对话完成，推送给 Graph
 			m.roundMu.Lock()
 			question := m.currentQuestion.String()
 			answer := m.currentAnswer.String()
+			snapshot, store := m.recordRound(question, answer)
 			m.currentQuestion.Reset()
 			m.currentAnswer.Reset()
+			m.currentImages = nil
 			m.roundMu.Unlock()
 
+			// 写文件较慢，释放 roundMu 后再保存，避免阻塞截图与转录
+			m.saveTranscript(store, snapshot)
+
 			if m.graph != nil && question != "" && answer != "" {
 				m.graph.Push(question, answer)
 			}
@@ -430,6 +479,7 @@ func (m *LiveSessionManager) receiveLoop() {
 		case llm.LiveMsgError:
 			// 服务端返回的错误，发送到 errorChan 统一处理
 			logger.Printf("[receiveLoop] Live: 服务端错误: %s", msg.Text)
+			stream.Flush()
 			m.errorChan <- &liveError{msg.Text}
 			return
 		}
@@ -557,6 +607,7 @@ func (m *LiveSessionManager) handleScreenshot(session llm.LiveSession, toolID st
 		cfg.Grayscale,
 		cfg.NoCompression,
 		cfg.ScreenshotMode,
+		cfg.ScreenshotRegion.Rect(),
 	)
 	if err != nil {
 		logger.Printf("[handleScreenshot] Live 截图失败: %v", err)
@@ -582,6 +633,11 @@ func (m *LiveSessionManager) handleScreenshot(session llm.LiveSession, toolID st
 		return
 	}
 
+	// 截图随本轮问答写入会话记录
+	m.roundMu.Lock()
+	m.currentImages = append(m.currentImages, llm.ImagePart(preview.Base64))
+	m.roundMu.Unlock()
+
 	err = session.SendToolResponseWithImage(toolID, imageData, mimeType)
 	if err != nil {
 		logger.Printf("[handleScreenshot] Live 发送截图失败: %v", err)
@@ -589,3 +645,47 @@ func (m *LiveSessionManager) handleScreenshot(session llm.LiveSession, toolID st
 		logger.Printf("[handleScreenshot] Live: 已发送屏幕截图给模型 (%d bytes, %s)", len(imageData), mimeType)
 	}
 }
+
+// recordRound 将一轮问答追加到会话记录，调用方需持有 roundMu
+// 返回会话记录的副本与存储，由调用方释放锁后保存；无需保存时返回 nil
+func (m *LiveSessionManager) recordRound(question, answer string) (*history.Conversation, *history.Store) {
+	if m.transcript == nil || (question == "" && answer == "") {
+		return nil, nil
+	}
+
+	parts := append([]llm.ContentPart(nil), m.currentImages...)
+	if question != "" {
+		parts = append(parts, llm.TextPart(question))
+	}
+	start := m.roundStart
+	if start.IsZero() {
+		start = time.Now()
+	}
+	m.transcript.AddTurn(history.Turn{
+		User:       llm.NewMultiPartMessage(llm.RoleUser, parts),
+		Model:      m.configManager.Get().Model,
+		Answer:     answer,
+		StartedAt:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+	m.roundStart = time.Time{}
+
+	if m.store == nil {
+		return nil, nil
+	}
+	conv := *m.transcript
+	conv.Turns = append([]history.Turn(nil), m.transcript.Turns...)
+	return &conv, m.store
+}
+
+// saveTranscript 保存会话记录副本，conv 为 nil 时不做任何事
+func (m *LiveSessionManager) saveTranscript(store *history.Store, conv *history.Conversation) {
+	if store == nil || conv == nil {
+		return
+	}
+	if err := store.Save(conv); err != nil {
+		logger.Printf("[Live] 保存会话记录失败: %v", err)
+		return
+	}
+	m.emitEvent("history-updated", conv.Summary())
+}
@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"Q-Solver/pkg/cache"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 模拟流式回放参数
+const (
+	replayChunkRunes = 24                    // 每个回放块的字符数
+	replayInterval   = 10 * time.Millisecond // 回放块间隔
+)
+
+// WithCache 为 Provider 包装响应缓存
+// 相同模型、参数和消息内容（含图片字节）的请求直接返回缓存结果
+func WithCache(cfg *config.Config, store *cache.DiskCache, p Provider) Provider {
+	if p == nil || store == nil {
+		return p
+	}
+	cached := &cachedProvider{
+		Provider: p,
+		config:   cfg,
+		store:    store,
+	}
+	if live, ok := p.(LiveProvider); ok {
+		return &cachedLiveProvider{cachedProvider: cached, live: live}
+	}
+	return cached
+}
+
+// noCacheKey 标记请求跳过响应缓存的 context 键
+type noCacheKey struct{}
+
+// WithoutCache 返回跳过响应缓存的 context，用于每次都需要重新生成的请求（如投票采样）
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// cacheBypassed 判断请求是否跳过响应缓存
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(noCacheKey{}).(bool)
+	return bypass
+}
+
+// cachedProvider 带响应缓存的 Provider 装饰器
+type cachedProvider struct {
+	Provider
+	config *config.Config
+	store  *cache.DiskCache
+}
+
+// cachedLiveProvider 同时支持 Live API 的装饰器（Live 会话不缓存）
+type cachedLiveProvider struct {
+	*cachedProvider
+	live LiveProvider
+}
+
+// ConnectLive 直接透传
+func (p *cachedLiveProvider) ConnectLive(ctx context.Context, cfg *LiveConfig) (LiveSession, error) {
+	return p.live.ConnectLive(ctx, cfg)
+}
+
+// GenerateContentStream 命中缓存时以模拟流式回放，否则请求模型并写入缓存
+func (p *cachedProvider) GenerateContentStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
+	if cacheBypassed(ctx) {
+		return p.Provider.GenerateContentStream(ctx, messages, onChunk)
+	}
+	key := p.cacheKey("stream", p.config.Model, messages)
+
+	if msg, ok := p.load(key); ok {
+		logger.Println("[Cache] 命中响应缓存，回放结果")
+		markCacheHit(ctx, true)
+		if err := replay(ctx, msg, onChunk); err != nil {
+			return Message{}, err
+		}
+		return msg, nil
+	}
+	markCacheHit(ctx, false)
+
+	msg, err := p.Provider.GenerateContentStream(ctx, messages, onChunk)
+	if err == nil && ctx.Err() == nil {
+		p.save(key, msg)
+	}
+	return msg, err
+}
+
+// GenerateContent 非流式生成内容（带缓存）
+func (p *cachedProvider) GenerateContent(ctx context.Context, model string, messages []Message) (Message, error) {
+	if cacheBypassed(ctx) {
+		return p.Provider.GenerateContent(ctx, model, messages)
+	}
+	keyModel := model
+	if keyModel == "" {
+		keyModel = p.config.Model
+	}
+	key := p.cacheKey("generate", keyModel, messages)
+
+	if msg, ok := p.load(key); ok {
+		logger.Println("[Cache] 命中响应缓存")
+		markCacheHit(ctx, true)
+		return msg, nil
+	}
+	markCacheHit(ctx, false)
+
+	msg, err := p.Provider.GenerateContent(ctx, model, messages)
+	if err == nil && ctx.Err() == nil {
+		p.save(key, msg)
+	}
+	return msg, err
+}
+
+// load 读取缓存的消息
+func (p *cachedProvider) load(key string) (Message, bool) {
+	data, ok := p.store.Get(key)
+	if !ok {
+		return Message{}, false
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Printf("[Cache] 解析缓存失败: %v", err)
+		return Message{}, false
+	}
+	// 缓存命中没有实际消耗 Token，避免重复统计
+	msg.Usage = nil
+	return msg, true
+}
+
+// save 写入缓存（空内容或被截断的回答不缓存）
+func (p *cachedProvider) save(key string, msg Message) {
+	if msg.Content == "" {
+		return
+	}
+	switch strings.ToLower(msg.FinishReason) {
+	case "length", "max_tokens":
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := p.store.Put(key, data); err != nil {
+		logger.Printf("[Cache] 写入缓存失败: %v", err)
+	}
+}
+
+// cacheKey 根据模型、生成参数和消息内容计算缓存键
+func (p *cachedProvider) cacheKey(operation string, model string, messages []Message) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|", operation, p.config.Provider, p.config.BaseURL, model)
+	fmt.Fprintf(h, "%g|%g|%d|%d|%d|", p.config.Temperature, p.config.TopP, p.config.TopK, p.config.MaxTokens, p.config.ThinkingBudget)
+	for _, msg := range messages {
+		writeMessageHash(h, msg)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeMessageHash 写入消息内容（不含用量等响应元数据）
+func writeMessageHash(h hash.Hash, msg Message) {
+	writeField(h, string(msg.Role))
+	writeField(h, msg.Content)
+	writeField(h, msg.Thinking)
+	for _, part := range msg.Parts {
+		writeField(h, string(part.Type))
+		writeField(h, part.Text)
+		writeField(h, part.Base64)
+	}
+}
+
+// writeField 以长度前缀写入字段，避免拼接歧义
+func writeField(w io.Writer, s string) {
+	fmt.Fprintf(w, "%d:", len(s))
+	io.WriteString(w, s)
+}
+
+// markCacheHit 在当前 span 上记录缓存命中情况
+func markCacheHit(ctx context.Context, hit bool) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("llm.cache.hit", hit))
+}
+
+// replay 将缓存的回答按块回放，模拟流式输出
+func replay(ctx context.Context, msg Message, onChunk StreamCallback) error {
+	if onChunk == nil {
+		return nil
+	}
+	if err := replayText(ctx, ChunkThinking, msg.Thinking, onChunk); err != nil {
+		return err
+	}
+	return replayText(ctx, ChunkContent, msg.Content, onChunk)
+}
+
+// replayText 回放一段文本
+func replayText(ctx context.Context, chunkType ChunkType, text string, onChunk StreamCallback) error {
+	runes := []rune(text)
+	for start := 0; start < len(runes); start += replayChunkRunes {
+		end := min(start+replayChunkRunes, len(runes))
+		onChunk(StreamChunk{Type: chunkType, Content: string(runes[start:end])})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(replayInterval):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"Q-Solver/pkg/config"
+	"testing"
+)
+
+func TestCacheKey(t *testing.T) {
+	base := func() (*config.Config, []Message) {
+		cfg := config.NewDefaultConfig()
+		messages := []Message{
+			NewSystemMessage("system"),
+			NewMultiPartMessage(RoleUser, []ContentPart{TextPart("q"), ImagePart("data:image/png;base64,AAAA")}),
+		}
+		return &cfg, messages
+	}
+	key := func(cfg *config.Config, model string, messages []Message) string {
+		p := &cachedProvider{config: cfg}
+		return p.cacheKey("stream", model, messages)
+	}
+	cfg, messages := base()
+	want := key(cfg, "m", messages)
+
+	if got := key(cfg, "m", messages); got != want {
+		t.Fatal("cacheKey is not deterministic")
+	}
+
+	tests := []struct {
+		name   string
+		change func(cfg *config.Config, messages []Message) string
+	}{
+		{"model", func(cfg *config.Config, messages []Message) string { return key(cfg, "other", messages) }},
+		{"temperature", func(cfg *config.Config, messages []Message) string {
+			cfg.Temperature += 0.1
+			return key(cfg, "m", messages)
+		}},
+		{"base url", func(cfg *config.Config, messages []Message) string {
+			cfg.BaseURL = "https://example.com"
+			return key(cfg, "m", messages)
+		}},
+		{"image bytes", func(cfg *config.Config, messages []Message) string {
+			messages[1].Parts[1] = ImagePart("data:image/png;base64,BBBB")
+			return key(cfg, "m", messages)
+		}},
+		{"role", func(cfg *config.Config, messages []Message) string {
+			messages[0].Role = RoleUser
+			return key(cfg, "m", messages)
+		}},
+		{"field boundary", func(cfg *config.Config, messages []Message) string {
+			// "q" + 图片与 "" + "q" + 图片 的拼接相同，长度前缀必须区分
+			messages[1].Content = "q"
+			messages[1].Parts[0] = TextPart("")
+			return key(cfg, "m", messages)
+		}},
+		{"operation", func(cfg *config.Config, messages []Message) string {
+			p := &cachedProvider{config: cfg}
+			return p.cacheKey("generate", "m", messages)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, messages := base()
+			if got := tt.change(cfg, messages); got == want {
+				t.Errorf("cacheKey did not change with %s", tt.name)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ type ClaudeAdapter struct {
 func NewClaudeAdapter(cfg *config.Config) *ClaudeAdapter {
 	opts := []option.RequestOption{
 		option.WithAPIKey(cfg.APIKey),
+		option.WithHTTPClient(NewHTTPClient(cfg)),
 	}
 	if cfg.Provider == "custom" {
 		baseUrl := strings.TrimSuffix(cfg.BaseURL, "/v1")
@@ -134,10 +135,21 @@ func (a *ClaudeAdapter) GenerateContentStream(ctx context.Context, messages []Me
 
 	var fullContent strings.Builder
 	var fullThinking strings.Builder
+	usage := &Usage{}
+	var finishReason string
 
 	for stream.Next() {
 		evt := stream.Current()
 
+		// 用量分别在 message_start（输入）和 message_delta（输出）中返回
+		switch evt.Type {
+		case "message_start":
+			usage.InputTokens = int(evt.Message.Usage.InputTokens)
+		case "message_delta":
+			usage.OutputTokens = int(evt.Usage.OutputTokens)
+			finishReason = string(evt.Delta.StopReason)
+		}
+
 		delta := evt.Delta
 		if delta.Text != "" {
 			fullContent.WriteString(delta.Text)
@@ -164,9 +176,11 @@ func (a *ClaudeAdapter) GenerateContentStream(ctx context.Context, messages []Me
 	}
 
 	return Message{
-		Role:     RoleAssistant,
-		Content:  fullContent.String(),
-		Thinking: fullThinking.String(),
+		Role:         RoleAssistant,
+		Content:      fullContent.String(),
+		Thinking:     fullThinking.String(),
+		Usage:        usage,
+		FinishReason: finishReason,
 	}, nil
 }
 
@@ -228,6 +242,11 @@ func (a *ClaudeAdapter) GenerateContent(ctx context.Context, model string, messa
 	return Message{
 		Role:    RoleAssistant,
 		Content: content,
+		Usage: &Usage{
+			InputTokens:  int(resp.Usage.InputTokens),
+			OutputTokens: int(resp.Usage.OutputTokens),
+		},
+		FinishReason: string(resp.StopReason),
 	}, nil
 }
 
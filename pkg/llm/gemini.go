@@ -21,16 +21,19 @@ type GeminiAdapter struct {
 // NewGeminiAdapter 创建 Gemini 适配器
 func NewGeminiAdapter(cfg *config.Config) (*GeminiAdapter, error) {
 	clientConfig := &genai.ClientConfig{
-		APIKey:  cfg.APIKey,
-		Backend: genai.BackendGeminiAPI,
+		APIKey:     cfg.APIKey,
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: NewHTTPClient(cfg),
+		HTTPOptions: genai.HTTPOptions{
+			// Live WebSocket 不经过 HTTPClient，额外请求头需通过 HTTPOptions 传入
+			Headers: ExtraHTTPHeaders(cfg),
+		},
 	}
 	//自定义的话就用自定义的URL
 	if cfg.Provider == "custom" {
 		baseUrl := strings.TrimSuffix(cfg.BaseURL, "/v1")
 		logger.Println("配置Gemini自定义URL", baseUrl)
-		clientConfig.HTTPOptions = genai.HTTPOptions{
-			BaseURL: baseUrl,
-		}
+		clientConfig.HTTPOptions.BaseURL = baseUrl
 	}
 
 	client, err := genai.NewClient(context.Background(), clientConfig)
@@ -204,12 +207,18 @@ func (a *GeminiAdapter) GenerateContentStream(ctx context.Context, messages []Me
 	logger.Printf("[Gemini] 开始流式请求，模型: %s", model)
 
 	chunkCount := 0
+	var usage *Usage
+	var finishReason string
 	for resp := range streamIter {
 		if resp == nil {
 			logger.Println("[Gemini] 收到 nil 响应，可能是迭代器错误")
 			continue
 		}
 
+		if resp.UsageMetadata != nil {
+			usage = toGeminiUsage(resp.UsageMetadata)
+		}
+
 		// 检查是否有错误（通过 PromptFeedback 或其他方式）
 		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
 			logger.Printf("[Gemini] 请求被阻止: %s", resp.PromptFeedback.BlockReason)
@@ -250,6 +259,7 @@ func (a *GeminiAdapter) GenerateContentStream(ctx context.Context, messages []Me
 
 			// 检查结束原因
 			if candidate.FinishReason != "" {
+				finishReason = string(candidate.FinishReason)
 				logger.Printf("[Gemini] 结束原因: %s", candidate.FinishReason)
 			}
 		}
@@ -259,12 +269,23 @@ func (a *GeminiAdapter) GenerateContentStream(ctx context.Context, messages []Me
 
 	// 返回最终结果
 	return Message{
-		Role:     RoleAssistant,
-		Content:  fullContent.String(),
-		Thinking: fullThinking.String(),
+		Role:         RoleAssistant,
+		Content:      fullContent.String(),
+		Thinking:     fullThinking.String(),
+		Usage:        usage,
+		FinishReason: finishReason,
 	}, nil
 }
 
+// toGeminiUsage 转换 Token 用量
+func toGeminiUsage(u *genai.GenerateContentResponseUsageMetadata) *Usage {
+	return &Usage{
+		InputTokens:    int(u.PromptTokenCount),
+		OutputTokens:   int(u.CandidatesTokenCount),
+		ThinkingTokens: int(u.ThoughtsTokenCount),
+	}
+}
+
 // TestChat 测试连通性
 func (a *GeminiAdapter) TestChat(ctx context.Context) error {
 	contents := []*genai.Content{
@@ -318,17 +339,26 @@ func (a *GeminiAdapter) GenerateContent(ctx context.Context, model string, messa
 
 	// 提取内容
 	var content string
+	var finishReason string
 	if resp != nil && len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
 		for _, part := range resp.Candidates[0].Content.Parts {
 			if part.Text != "" {
 				content += part.Text
 			}
 		}
+		finishReason = string(resp.Candidates[0].FinishReason)
+	}
+
+	var usage *Usage
+	if resp != nil && resp.UsageMetadata != nil {
+		usage = toGeminiUsage(resp.UsageMetadata)
 	}
 
 	return Message{
-		Role:    RoleAssistant,
-		Content: content,
+		Role:         RoleAssistant,
+		Content:      content,
+		Usage:        usage,
+		FinishReason: finishReason,
 	}, nil
 }
 
@@ -60,7 +60,9 @@ func (a *GeminiAdapter) ConnectLive(ctx context.Context, cfg *LiveConfig) (LiveS
 		Parts: []*genai.Part{{Text: instructionText}},
 	}
 
+	release := configureLiveDialer(a.config)
 	session, err := a.client.Live.Connect(ctx, model, connectCfg)
+	release()
 	if err != nil {
 		logger.Printf("LiveAPI: 连接到模型 %s 发生错误", err)
 		return nil, err
@@ -0,0 +1,31 @@
+package llm
+
+// AttachmentLimits 单次请求中附件的大小上限（按原始字节计算，base64 编码后约增大 1/3）
+type AttachmentLimits struct {
+	Image int64 `json:"image"` // 单张图片
+	PDF   int64 `json:"pdf"`   // 单个 PDF
+	Text  int64 `json:"text"`  // 单个文本文件
+	Total int64 `json:"total"` // 所有附件合计
+}
+
+const (
+	kb = 1 << 10
+	mb = 1 << 20
+)
+
+// LimitsFor 返回提供商的附件大小上限
+func LimitsFor(providerType ProviderType) AttachmentLimits {
+	switch providerType {
+	case ProviderClaude:
+		// 单张图片编码后不超过 5MB，整个请求不超过 32MB
+		return AttachmentLimits{Image: 3750 * kb, PDF: 20 * mb, Text: 512 * kb, Total: 24 * mb}
+	case ProviderGemini:
+		// 内联数据整个请求不超过 20MB
+		return AttachmentLimits{Image: 14 * mb, PDF: 14 * mb, Text: 512 * kb, Total: 14 * mb}
+	case ProviderCustom:
+		// 兼容接口的限制各不相同，按较保守的值处理
+		return AttachmentLimits{Image: 10 * mb, PDF: 10 * mb, Text: 512 * kb, Total: 14 * mb}
+	default:
+		return AttachmentLimits{Image: 20 * mb, PDF: 24 * mb, Text: 512 * kb, Total: 36 * mb}
+	}
+}
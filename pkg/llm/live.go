@@ -2,6 +2,7 @@ package llm
 
 import (
 	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/prompts"
 	"context"
 )
 
@@ -76,7 +77,7 @@ type LiveProvider interface {
 func GetLiveConfig(cfg config.Config) *LiveConfig {
 	return &LiveConfig{
 		Model:             cfg.Model,
-		SystemInstruction: cfg.Prompt,
+		SystemInstruction: cfg.Prompt + prompts.LanguageInstruction(cfg.AnswerLanguage, cfg.CodeLanguages),
 		MaxTokens:         cfg.MaxTokens,
 		Temperature:       cfg.Temperature,
 		TopP:              cfg.TopP,
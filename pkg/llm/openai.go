@@ -2,13 +2,15 @@ package llm
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
 
 	openai "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -19,6 +21,9 @@ type OpenAIAdapter struct {
 	client     *openai.Client
 	httpClient *http.Client
 	config     *config.Config
+
+	// usageUnsupported 兼容接口不接受 stream_options 时置位，之后的请求不再携带
+	usageUnsupported atomic.Bool
 }
 
 // NewOpenAIAdapter 创建 OpenAI 适配器
@@ -27,14 +32,7 @@ func NewOpenAIAdapter(cfg *config.Config) *OpenAIAdapter {
 	if model == "" {
 		model = openai.ChatModelGPT4o
 	}
-	// proxyUrl, _ := url.Parse("http://127.0.0.1:8888")
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{},
-		// Proxy: http.ProxyURL(proxyUrl),
-	}
-	httpClient := &http.Client{
-		Transport: transport,
-	}
+	httpClient := NewHTTPClient(cfg)
 
 	opts := []option.RequestOption{
 		option.WithAPIKey(cfg.APIKey),
@@ -101,26 +99,72 @@ func (a *OpenAIAdapter) toOpenAIParts(parts []ContentPart) []openai.ChatCompleti
 // ==================== Provider 接口实现 ====================
 
 // GenerateContentStream 流式生成内容
+// 默认请求携带 include_usage 以获取用量；部分兼容接口不认识该参数并返回 400，此时去掉参数重试一次
 func (a *OpenAIAdapter) GenerateContentStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
-	openaiMessages := a.toOpenAIMessages(messages)
-
-	stream := a.client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+	params := openai.ChatCompletionNewParams{
 		Model:       a.config.Model,
-		Messages:    openaiMessages,
+		Messages:    a.toOpenAIMessages(messages),
 		Temperature: openai.Float(a.config.Temperature),
 		TopP:        openai.Float(a.config.TopP),
 		MaxTokens:   openai.Int(int64(a.config.MaxTokens)),
+	}
+	if a.usageUnsupported.Load() {
+		return a.stream(ctx, params, onChunk)
+	}
+
+	withUsage := params
+	withUsage.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
+	}
+	emitted := false
+	msg, err := a.stream(ctx, withUsage, func(chunk StreamChunk) {
+		emitted = true
+		if onChunk != nil {
+			onChunk(chunk)
+		}
 	})
+	if err == nil || emitted || !isBadRequest(err) {
+		return msg, err
+	}
+
+	msg, retryErr := a.stream(ctx, params, onChunk)
+	if retryErr != nil {
+		return msg, retryErr
+	}
+	logger.Printf("[OpenAI] 接口不支持 stream_options，后续请求不再获取用量: %v", err)
+	a.usageUnsupported.Store(true)
+	return msg, nil
+}
+
+// isBadRequest 请求被接口以 400 拒绝
+func isBadRequest(err error) bool {
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
+// stream 发送一次流式请求，逐块回调并拼接完整回答
+func (a *OpenAIAdapter) stream(ctx context.Context, params openai.ChatCompletionNewParams, onChunk StreamCallback) (Message, error) {
+	stream := a.client.Chat.Completions.NewStreaming(ctx, params)
 
 	defer stream.Close()
 
 	var fullContent strings.Builder
 	var fullThinking strings.Builder
+	var usage *Usage
+	var finishReason string
 
 	for stream.Next() {
 		evt := stream.Current()
 
+		// 开启 include_usage 后，最后一个 chunk 携带整个请求的用量
+		if evt.Usage.TotalTokens > 0 {
+			usage = toOpenAIUsage(evt.Usage)
+		}
+
 		if len(evt.Choices) > 0 {
+			if evt.Choices[0].FinishReason != "" {
+				finishReason = evt.Choices[0].FinishReason
+			}
 			delta := evt.Choices[0].Delta
 			content := delta.Content
 
@@ -142,19 +186,30 @@ func (a *OpenAIAdapter) GenerateContentStream(ctx context.Context, messages []Me
 	}
 
 	return Message{
-		Role:     RoleAssistant,
-		Content:  fullContent.String(),
-		Thinking: fullThinking.String(),
+		Role:         RoleAssistant,
+		Content:      fullContent.String(),
+		Thinking:     fullThinking.String(),
+		Usage:        usage,
+		FinishReason: finishReason,
 	}, nil
 }
 
+// toOpenAIUsage 转换 Token 用量
+func toOpenAIUsage(u openai.CompletionUsage) *Usage {
+	return &Usage{
+		InputTokens:    int(u.PromptTokens),
+		OutputTokens:   int(u.CompletionTokens),
+		ThinkingTokens: int(u.CompletionTokensDetails.ReasoningTokens),
+	}
+}
+
 // parseError 解析错误信息
 func (a *OpenAIAdapter) parseError(err error) error {
 	errStr := err.Error()
 
 	startIndex := strings.Index(errStr, "{")
 	if startIndex == -1 {
-		return fmt.Errorf("未知错误: %s", errStr)
+		return &sdkError{msg: "未知错误: " + errStr, err: err}
 	}
 
 	jsonPart := errStr[startIndex:]
@@ -180,12 +235,21 @@ func (a *OpenAIAdapter) parseError(err error) error {
 
 	finalJsonBytes, marshalErr := json.Marshal(response)
 	if marshalErr != nil {
-		return fmt.Errorf("解析错误: %s", response.Message)
+		return &sdkError{msg: "解析错误: " + response.Message, err: err}
 	}
 
-	return fmt.Errorf("%s", string(finalJsonBytes))
+	return &sdkError{msg: string(finalJsonBytes), err: err}
 }
 
+// sdkError 改写错误信息的同时保留 SDK 原始错误，便于按状态码归类
+type sdkError struct {
+	msg string
+	err error
+}
+
+func (e *sdkError) Error() string { return e.msg }
+func (e *sdkError) Unwrap() error { return e.err }
+
 // TestChat 测试连通性
 func (a *OpenAIAdapter) TestChat(ctx context.Context) error {
 	_, err := a.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
@@ -219,13 +283,17 @@ func (a *OpenAIAdapter) GenerateContent(ctx context.Context, model string, messa
 	}
 
 	content := ""
+	finishReason := ""
 	if len(resp.Choices) > 0 {
 		content = resp.Choices[0].Message.Content
+		finishReason = resp.Choices[0].FinishReason
 	}
 
 	return Message{
-		Role:    RoleAssistant,
-		Content: content,
+		Role:         RoleAssistant,
+		Content:      content,
+		Usage:        toOpenAIUsage(resp.Usage),
+		FinishReason: finishReason,
 	}, nil
 }
 
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"Q-Solver/pkg/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newStreamServer 模拟不支持 stream_options 的兼容接口
+func newStreamServer(t *testing.T, rejectUsage bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if _, ok := body["stream_options"]; ok && rejectUsage {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"unknown field stream_options","type":"invalid_request_error"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestOpenAIStreamRetriesWithoutUsage(t *testing.T) {
+	tests := []struct {
+		name         string
+		rejectUsage  bool
+		wantRequests int32
+		wantDisabled bool
+	}{
+		{"supported", false, 2, false},
+		{"rejected", true, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newStreamServer(t, tt.rejectUsage)
+			cfg := config.NewDefaultConfig()
+			cfg.BaseURL = server.URL
+			cfg.APIKey = "test"
+			cfg.Proxy = config.ProxyDirect
+			adapter := NewOpenAIAdapter(&cfg)
+
+			// 第二次请求验证是否记住了接口不支持 stream_options
+			for i := 0; i < 2; i++ {
+				msg, err := adapter.GenerateContentStream(t.Context(), []Message{NewUserMessage("q")}, nil)
+				if err != nil {
+					t.Fatalf("GenerateContentStream: %v", err)
+				}
+				if msg.Content != "hi" {
+					t.Errorf("Content = %q, want hi", msg.Content)
+				}
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if got := adapter.usageUnsupported.Load(); got != tt.wantDisabled {
+				t.Errorf("usageUnsupported = %v, want %v", got, tt.wantDisabled)
+			}
+		})
+	}
+}
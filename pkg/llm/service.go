@@ -3,9 +3,11 @@ package llm
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"Q-Solver/pkg/cache"
 	"Q-Solver/pkg/config"
 	"Q-Solver/pkg/logger"
 )
@@ -24,12 +26,14 @@ const (
 type Service struct {
 	config   config.Config // 存储配置副本，不是指针
 	provider Provider
+	cache    *cache.DiskCache // 响应缓存（ResponseCache 关闭时不使用）
 }
 
 // NewService 创建 LLM 服务
 func NewService(cfg config.Config, cm *config.ConfigManager) *Service {
 	s := &Service{
 		config: cfg, // 存储配置副本
+		cache:  cache.NewDiskCache(filepath.Join(cm.GetConfigDir(), "cache", "responses")),
 	}
 	s.UpdateProvider()
 
@@ -46,7 +50,80 @@ func NewService(cfg config.Config, cm *config.ConfigManager) *Service {
 // UpdateProvider 更新 Provider（配置变更时调用）
 func (s *Service) UpdateProvider() {
 	providerType := DetectProviderType(s.config.Provider)
-	s.provider = CreateProvider(providerType, &s.config) // 传递配置的指针给 Provider
+	provider := CreateProvider(providerType, &s.config) // 传递配置的指针给 Provider
+	if s.config.ResponseCache {
+		s.cache.SetLimits(
+			time.Duration(s.config.ResponseCacheTTL)*time.Minute,
+			int64(s.config.ResponseCacheMaxMB)*1024*1024,
+		)
+		provider = WithCache(&s.config, s.cache, provider)
+	}
+	s.provider = WithTelemetry(providerType, &s.config, provider)
+}
+
+// GetProviderForModel 创建使用指定模型的 Provider（其余配置与当前一致）
+// 用于需要同时调用多个模型的场景，model 为空时返回当前 Provider
+func (s *Service) GetProviderForModel(model string) Provider {
+	if model == "" || model == s.config.Model {
+		return s.provider
+	}
+	modelConfig := s.config
+	modelConfig.Model = model
+	return s.newProvider(modelConfig)
+}
+
+// GetProviderForTarget 创建对比模型使用的 Provider，未指定的字段沿用当前配置
+func (s *Service) GetProviderForTarget(target config.CompareTarget) Provider {
+	targetConfig := s.config
+	targetConfig.Model = target.Model
+	if target.Provider != "" {
+		targetConfig.Provider = target.Provider
+	}
+	if target.APIKey != "" {
+		targetConfig.APIKey = target.APIKey
+	}
+	if target.BaseURL != "" {
+		targetConfig.BaseURL = target.BaseURL
+	}
+	return s.newProvider(targetConfig)
+}
+
+// GetProviderForConfig 创建使用指定配置的 Provider（解题方案覆盖了模型或生成参数时使用）
+// 模型与生成参数和当前配置一致时直接返回当前 Provider
+func (s *Service) GetProviderForConfig(cfg config.Config) Provider {
+	if sameGeneration(cfg, s.config) {
+		return s.provider
+	}
+	providerType := DetectProviderType(cfg.Provider)
+	provider := CreateProvider(providerType, &cfg)
+	if cfg.ResponseCache {
+		provider = WithCache(&cfg, s.cache, provider)
+	}
+	return WithTelemetry(providerType, &cfg, provider)
+}
+
+// sameGeneration 判断两份配置创建的 Provider 是否等价
+func sameGeneration(a, b config.Config) bool {
+	return a.Provider == b.Provider &&
+		a.APIKey == b.APIKey &&
+		a.BaseURL == b.BaseURL &&
+		a.Model == b.Model &&
+		a.Temperature == b.Temperature &&
+		a.TopP == b.TopP &&
+		a.TopK == b.TopK &&
+		a.MaxTokens == b.MaxTokens &&
+		a.ThinkingBudget == b.ThinkingBudget
+}
+
+// newProvider 根据配置副本创建带追踪的 Provider（不使用响应缓存）
+func (s *Service) newProvider(cfg config.Config) Provider {
+	providerType := DetectProviderType(cfg.Provider)
+	return WithTelemetry(providerType, &cfg, CreateProvider(providerType, &cfg))
+}
+
+// ClearCache 清空响应缓存
+func (s *Service) ClearCache() error {
+	return s.cache.Clear()
 }
 
 // GetProvider 获取当前 Provider
@@ -0,0 +1,358 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	openai "github.com/openai/openai-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+)
+
+// instrumentationName OpenTelemetry instrumentation 名称
+const instrumentationName = "Q-Solver/pkg/llm"
+
+// 错误分类（用于 span 属性与指标维度）
+const (
+	ErrCategoryCanceled  = "canceled"
+	ErrCategoryTimeout   = "timeout"
+	ErrCategoryNetwork   = "network"
+	ErrCategoryAuth      = "auth"
+	ErrCategoryRateLimit = "rate_limit"
+	ErrCategoryInvalid   = "invalid_request"
+	ErrCategoryServer    = "server"
+	ErrCategoryOther     = "other"
+)
+
+// llmInstruments LLM 调用相关指标
+type llmInstruments struct {
+	requests   metric.Int64Counter
+	duration   metric.Float64Histogram
+	firstChunk metric.Float64Histogram
+	tokens     metric.Int64Counter
+	imageBytes metric.Int64Counter
+}
+
+var (
+	instruments         *llmInstruments
+	instrumentsProvider metric.MeterProvider
+	instrumentsMu       sync.Mutex
+)
+
+// getInstruments 获取指标，全局 MeterProvider 变化（导出配置变更）后重新创建
+func getInstruments() *llmInstruments {
+	instrumentsMu.Lock()
+	defer instrumentsMu.Unlock()
+
+	mp := otel.GetMeterProvider()
+	if instruments != nil && instrumentsProvider == mp {
+		return instruments
+	}
+
+	meter := mp.Meter(instrumentationName)
+	inst := &llmInstruments{}
+	var errs []error
+	var err error
+
+	inst.requests, err = meter.Int64Counter("llm.requests",
+		metric.WithDescription("LLM 请求次数"))
+	errs = append(errs, err)
+	inst.duration, err = meter.Float64Histogram("llm.request.duration",
+		metric.WithDescription("LLM 请求总耗时"), metric.WithUnit("s"))
+	errs = append(errs, err)
+	inst.firstChunk, err = meter.Float64Histogram("llm.time_to_first_chunk",
+		metric.WithDescription("首个流式输出块的等待时间"), metric.WithUnit("s"))
+	errs = append(errs, err)
+	inst.tokens, err = meter.Int64Counter("llm.tokens",
+		metric.WithDescription("Token 用量"))
+	errs = append(errs, err)
+	inst.imageBytes, err = meter.Int64Counter("llm.request.image_bytes",
+		metric.WithDescription("请求中附带的图片/文档字节数"), metric.WithUnit("By"))
+	errs = append(errs, err)
+
+	if err := errors.Join(errs...); err != nil {
+		logger.Printf("创建 LLM 指标失败: %v", err)
+	}
+
+	instruments = inst
+	instrumentsProvider = mp
+	return inst
+}
+
+// WithTelemetry 为 Provider 包装链路追踪与指标采集
+// 未初始化 OpenTelemetry SDK 时使用全局 noop 实现，开销可忽略
+func WithTelemetry(providerType ProviderType, cfg *config.Config, p Provider) Provider {
+	if p == nil {
+		return nil
+	}
+	traced := &tracedProvider{
+		Provider:     p,
+		providerType: providerType,
+		config:       cfg,
+	}
+	if live, ok := p.(LiveProvider); ok {
+		return &tracedLiveProvider{tracedProvider: traced, live: live}
+	}
+	return traced
+}
+
+// tracedProvider 带追踪的 Provider 装饰器
+type tracedProvider struct {
+	Provider
+	providerType ProviderType
+	config       *config.Config
+}
+
+// tracer 每次调用时获取，保证导出配置变更后使用新的 TracerProvider
+func (p *tracedProvider) tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// tracedLiveProvider 同时支持 Live API 的装饰器
+type tracedLiveProvider struct {
+	*tracedProvider
+	live LiveProvider
+}
+
+// GenerateContentStream 流式生成内容（带追踪）
+func (p *tracedProvider) GenerateContentStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
+	model := p.config.Model
+	ctx, span := p.startSpan(ctx, "llm.GenerateContentStream", model, messages)
+	defer span.End()
+
+	start := time.Now()
+	var firstChunkOnce sync.Once
+	var firstChunk time.Duration
+
+	wrapped := func(chunk StreamChunk) {
+		firstChunkOnce.Do(func() {
+			firstChunk = time.Since(start)
+			span.AddEvent("first_chunk")
+		})
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	}
+
+	resp, err := p.Provider.GenerateContentStream(ctx, messages, wrapped)
+
+	attrs := p.commonAttrs(model, "stream")
+	if firstChunk > 0 {
+		span.SetAttributes(attribute.Int64("llm.time_to_first_chunk_ms", firstChunk.Milliseconds()))
+		getInstruments().firstChunk.Record(ctx, firstChunk.Seconds(), metric.WithAttributes(attrs...))
+	}
+	p.finish(ctx, span, start, attrs, resp, err)
+	return resp, err
+}
+
+// GenerateContent 非流式生成内容（带追踪）
+func (p *tracedProvider) GenerateContent(ctx context.Context, model string, messages []Message) (Message, error) {
+	spanModel := model
+	if spanModel == "" {
+		spanModel = p.config.Model
+	}
+	ctx, span := p.startSpan(ctx, "llm.GenerateContent", spanModel, messages)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := p.Provider.GenerateContent(ctx, model, messages)
+	p.finish(ctx, span, start, p.commonAttrs(spanModel, "generate"), resp, err)
+	return resp, err
+}
+
+// ConnectLive 建立 Live 连接（带追踪，仅覆盖连接建立阶段）
+func (p *tracedLiveProvider) ConnectLive(ctx context.Context, cfg *LiveConfig) (LiveSession, error) {
+	model := cfg.Model
+	if model == "" {
+		model = p.config.Model
+	}
+	ctx, span := p.tracer().Start(ctx, "llm.ConnectLive", trace.WithAttributes(
+		attribute.String("gen_ai.system", string(p.providerType)),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Bool("llm.live.resume", cfg.ResumeToken != ""),
+	))
+	defer span.End()
+
+	start := time.Now()
+	session, err := p.live.ConnectLive(ctx, cfg)
+	p.finish(ctx, span, start, p.commonAttrs(model, "live_connect"), Message{}, err)
+	return session, err
+}
+
+// startSpan 创建 span 并记录请求侧属性
+func (p *tracedProvider) startSpan(ctx context.Context, name string, model string, messages []Message) (context.Context, trace.Span) {
+	images, imageBytes := countAttachments(messages)
+	if imageBytes > 0 {
+		getInstruments().imageBytes.Add(ctx, int64(imageBytes), metric.WithAttributes(p.commonAttrs(model, "")...))
+	}
+	return p.tracer().Start(ctx, name, trace.WithAttributes(
+		attribute.String("gen_ai.system", string(p.providerType)),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Int("llm.messages.count", len(messages)),
+		attribute.Int("llm.messages.attachments", images),
+		attribute.Int("llm.request.image_bytes", imageBytes),
+	))
+}
+
+// finish 记录响应侧属性与指标
+func (p *tracedProvider) finish(ctx context.Context, span trace.Span, start time.Time, attrs []attribute.KeyValue, resp Message, err error) {
+	inst := getInstruments()
+	elapsed := time.Since(start)
+
+	status := "ok"
+	if err != nil {
+		status = ErrorCategory(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.String("error.type", status))
+	}
+	// Clip 后追加会重新分配，避免写入调用方 attrs 的底层数组
+	metricAttrs := append(slices.Clip(attrs), attribute.String("status", status))
+	inst.requests.Add(ctx, 1, metric.WithAttributes(metricAttrs...))
+	inst.duration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(metricAttrs...))
+
+	if resp.FinishReason != "" {
+		span.SetAttributes(attribute.String("gen_ai.response.finish_reason", resp.FinishReason))
+	}
+	if resp.Usage != nil {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", resp.Usage.InputTokens),
+			attribute.Int("gen_ai.usage.output_tokens", resp.Usage.OutputTokens),
+			attribute.Int("llm.usage.thinking_tokens", resp.Usage.ThinkingTokens),
+		)
+		inst.tokens.Add(ctx, int64(resp.Usage.InputTokens), metric.WithAttributes(append(attrs, attribute.String("token.type", "input"))...))
+		inst.tokens.Add(ctx, int64(resp.Usage.OutputTokens), metric.WithAttributes(append(attrs, attribute.String("token.type", "output"))...))
+		if resp.Usage.ThinkingTokens > 0 {
+			inst.tokens.Add(ctx, int64(resp.Usage.ThinkingTokens), metric.WithAttributes(append(attrs, attribute.String("token.type", "thinking"))...))
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("llm.response.content_length", len(resp.Content)),
+		attribute.Int("llm.response.thinking_length", len(resp.Thinking)),
+	)
+}
+
+// commonAttrs 指标公共维度
+func (p *tracedProvider) commonAttrs(model string, operation string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.system", string(p.providerType)),
+		attribute.String("gen_ai.request.model", model),
+	}
+	if operation != "" {
+		attrs = append(attrs, attribute.String("operation", operation))
+	}
+	return attrs
+}
+
+// countAttachments 统计消息中的图片/PDF 数量与解码后字节数
+func countAttachments(messages []Message) (count int, bytes int) {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type != ContentImage && part.Type != ContentPDF {
+				continue
+			}
+			count++
+			_, data := ParseBase64DataURL(part.Base64)
+			bytes += len(data) * 3 / 4
+		}
+	}
+	return count, bytes
+}
+
+// ErrorCategory 将错误归类，便于跨厂商比较
+func ErrorCategory(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrCategoryCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrCategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrCategoryTimeout
+		}
+		return ErrCategoryNetwork
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrCategoryNetwork
+	}
+
+	switch code := statusCode(err); {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrCategoryAuth
+	case code == http.StatusTooManyRequests || code == statusOverloaded:
+		return ErrCategoryRateLimit
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return ErrCategoryTimeout
+	case code >= 500:
+		return ErrCategoryServer
+	case code >= 400:
+		return ErrCategoryInvalid
+	}
+
+	// 没有状态码的错误（流式响应中途的错误等）按明确的错误描述归类
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, "unauthorized", "permission denied", "invalid api key", "api key not valid"):
+		return ErrCategoryAuth
+	case containsAny(msg, "rate limit", "rate_limit", "quota", "resource_exhausted", "overloaded"):
+		return ErrCategoryRateLimit
+	case containsAny(msg, "context length", "context_length_exceeded", "prompt is too long"):
+		return ErrCategoryInvalid
+	case containsAny(msg, "connection refused", "connection reset", "no such host", "tls:", "proxyconnect"):
+		return ErrCategoryNetwork
+	}
+	return ErrCategoryOther
+}
+
+// statusOverloaded Anthropic 服务过载时返回的状态码
+const statusOverloaded = 529
+
+// statusCode 从各厂商 SDK 的错误类型中取出 HTTP 状态码，不是 API 错误时返回 0
+func statusCode(err error) int {
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return openaiErr.StatusCode
+	}
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.StatusCode
+	}
+	var genaiErr genai.APIError
+	if errors.As(err, &genaiErr) {
+		return genaiErr.Code
+	}
+	var genaiErrPtr *genai.APIError
+	if errors.As(err, &genaiErrPtr) {
+		return genaiErrPtr.Code
+	}
+	return 0
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
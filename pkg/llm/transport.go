@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+// NewHTTPClient 根据配置创建所有适配器共用的 HTTP 客户端
+// 配置有误时记录日志并退回默认行为，不阻断 Provider 创建
+func NewHTTPClient(cfg *config.Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(cfg.ConnectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:               proxyFunc(cfg.Proxy),
+		DialContext:         readTimeoutDialer(dialer, time.Duration(cfg.ReadTimeout)*time.Second),
+		TLSClientConfig:     tlsConfig(cfg.CACertPath),
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	var rt http.RoundTripper = transport
+	if len(cfg.ExtraHeaders) > 0 {
+		rt = &headerRoundTripper{base: transport, headers: cfg.ExtraHeaders}
+	}
+
+	// 不设置 http.Client.Timeout，否则会截断长时间的流式输出
+	return &http.Client{Transport: rt}
+}
+
+// ExtraHTTPHeaders 返回配置中的额外请求头（http.Header 格式）
+func ExtraHTTPHeaders(cfg *config.Config) http.Header {
+	if len(cfg.ExtraHeaders) == 0 {
+		return nil
+	}
+	headers := make(http.Header, len(cfg.ExtraHeaders))
+	for k, v := range cfg.ExtraHeaders {
+		headers.Set(k, v)
+	}
+	return headers
+}
+
+// proxyFunc 解析代理配置
+func proxyFunc(proxy string) func(*http.Request) (*url.URL, error) {
+	proxy = strings.TrimSpace(proxy)
+	switch proxy {
+	case "", config.ProxySystem:
+		return http.ProxyFromEnvironment
+	case config.ProxyDirect:
+		return nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		logger.Printf("代理地址无效，忽略代理设置: %s", proxy)
+		return nil
+	}
+	return http.ProxyURL(proxyURL)
+}
+
+// tlsConfig 构建 TLS 配置，追加自定义 CA 证书
+func tlsConfig(caCertPath string) *tls.Config {
+	tlsCfg := &tls.Config{}
+	if caCertPath == "" {
+		return tlsCfg
+	}
+
+	pool, err := loadCertPool(caCertPath)
+	if err != nil {
+		logger.Printf("加载自定义 CA 证书失败: %v", err)
+		return tlsCfg
+	}
+	tlsCfg.RootCAs = pool
+	return tlsCfg
+}
+
+// loadCertPool 在系统证书基础上追加 PEM 证书
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("证书文件中没有有效的 PEM 证书: %s", path)
+	}
+	return pool, nil
+}
+
+// readTimeoutDialer 为每个连接设置读取超时（两次收到数据之间的最长间隔）
+func readTimeoutDialer(dialer *net.Dialer, readTimeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil || readTimeout <= 0 {
+			return conn, err
+		}
+		return &deadlineConn{Conn: conn, readTimeout: readTimeout}, nil
+	}
+}
+
+// deadlineConn 每次读取前刷新读超时
+type deadlineConn struct {
+	net.Conn
+	readTimeout time.Duration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// headerRoundTripper 为每个请求附加额外请求头
+type headerRoundTripper struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range rt.headers {
+		req.Header.Set(k, v)
+	}
+	return rt.base.RoundTrip(req)
+}
+
+// liveDialerMu 保护 websocket.DefaultDialer（genai Live 只使用默认 Dialer）
+var liveDialerMu sync.Mutex
+
+// configureLiveDialer 将代理、连接超时和证书配置应用到 Live WebSocket 连接
+// Live 会话在无人说话时可能长时间收不到数据，因此不设置读取超时
+// 返回的函数在连接建立后恢复默认 Dialer 原有的配置并释放锁，不影响进程内其他 WebSocket 连接
+func configureLiveDialer(cfg *config.Config) func() {
+	liveDialerMu.Lock()
+	previous := *websocket.DefaultDialer
+
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(cfg.ConnectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	websocket.DefaultDialer.Proxy = proxyFunc(cfg.Proxy)
+	websocket.DefaultDialer.NetDialContext = dialer.DialContext
+	websocket.DefaultDialer.TLSClientConfig = tlsConfig(cfg.CACertPath)
+	if cfg.ConnectTimeout > 0 {
+		websocket.DefaultDialer.HandshakeTimeout = time.Duration(cfg.ConnectTimeout) * time.Second
+	} else {
+		websocket.DefaultDialer.HandshakeTimeout = 45 * time.Second
+	}
+
+	return func() {
+		*websocket.DefaultDialer = previous
+		liveDialerMu.Unlock()
+	}
+}
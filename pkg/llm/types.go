@@ -39,12 +39,21 @@ type ContentPart struct {
 	Base64 string      `json:"base64,omitempty"` // 包含 data:xxx;base64, 前缀
 }
 
+// Usage Token 用量（由各适配器在响应中填充，未返回时为 nil）
+type Usage struct {
+	InputTokens    int `json:"inputTokens,omitempty"`
+	OutputTokens   int `json:"outputTokens,omitempty"`
+	ThinkingTokens int `json:"thinkingTokens,omitempty"`
+}
+
 // Message 统一的消息格式
 type Message struct {
-	Role     Role          `json:"role"`
-	Content  string        `json:"content,omitempty"`  // 纯文本内容
-	Parts    []ContentPart `json:"parts,omitempty"`    // 多模态内容
-	Thinking string        `json:"thinking,omitempty"` // 思维链（仅 Assistant）
+	Role         Role          `json:"role"`
+	Content      string        `json:"content,omitempty"`      // 纯文本内容
+	Parts        []ContentPart `json:"parts,omitempty"`        // 多模态内容
+	Thinking     string        `json:"thinking,omitempty"`     // 思维链（仅 Assistant）
+	Usage        *Usage        `json:"usage,omitempty"`        // Token 用量（仅模型返回）
+	FinishReason string        `json:"finishReason,omitempty"` // 结束原因（仅模型返回）
 }
 
 // StreamCallback 统一的流式回调
@@ -0,0 +1,58 @@
+//go:build darwin
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#import <Cocoa/Cocoa.h>
+#include <stdlib.h>
+#include <string.h>
+
+// 读取剪贴板图片并统一转换为 PNG，没有图片时返回 NULL
+void* ReadClipboardPNGC(int* length) {
+	*length = 0;
+	@autoreleasepool {
+		NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+		NSData *data = [pasteboard dataForType:NSPasteboardTypePNG];
+		if (data == nil) {
+			NSData *tiff = [pasteboard dataForType:NSPasteboardTypeTIFF];
+			if (tiff == nil) {
+				return NULL;
+			}
+			NSBitmapImageRep *rep = [NSBitmapImageRep imageRepWithData:tiff];
+			if (rep == nil) {
+				return NULL;
+			}
+			data = [rep representationUsingType:NSBitmapImageFileTypePNG properties:@{}];
+		}
+		if (data == nil || [data length] == 0) {
+			return NULL;
+		}
+		void *buf = malloc([data length]);
+		memcpy(buf, [data bytes], [data length]);
+		*length = (int)[data length];
+		return buf;
+	}
+}
+*/
+import "C"
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"unsafe"
+)
+
+// ReadClipboardImage 读取剪贴板中的图片，剪贴板中没有图片时返回 nil
+func ReadClipboardImage() (image.Image, error) {
+	var length C.int
+	buf := C.ReadClipboardPNGC(&length)
+	if buf == nil {
+		return nil, nil
+	}
+	defer C.free(buf)
+
+	data := C.GoBytes(unsafe.Pointer(buf), length)
+	return png.Decode(bytes.NewReader(data))
+}
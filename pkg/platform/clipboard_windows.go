@@ -0,0 +1,152 @@
+//go:build windows
+
+package platform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	procOpenClipboard              = user32.NewProc("OpenClipboard")
+	procCloseClipboard             = user32.NewProc("CloseClipboard")
+	procIsClipboardFormatAvailable = user32.NewProc("IsClipboardFormatAvailable")
+	procGetClipboardData           = user32.NewProc("GetClipboardData")
+	procRegisterClipboardFormatW   = user32.NewProc("RegisterClipboardFormatW")
+	procGlobalLock                 = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock               = kernel32.NewProc("GlobalUnlock")
+	procGlobalSize                 = kernel32.NewProc("GlobalSize")
+	procRtlMoveMemory              = kernel32.NewProc("RtlMoveMemory")
+)
+
+// 剪贴板格式与位图压缩方式
+const (
+	cfDIB        = 8
+	biRGB        = 0
+	biBitfields  = 3
+	openAttempts = 5
+)
+
+// ReadClipboardImage 读取剪贴板中的图片，剪贴板中没有图片时返回 nil
+// 优先读取浏览器等程序放入的 PNG 格式（保留透明度），其次读取系统位图 CF_DIB
+func ReadClipboardImage() (image.Image, error) {
+	// OpenClipboard 与 CloseClipboard 必须在同一线程调用
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := openClipboard(); err != nil {
+		return nil, err
+	}
+	defer procCloseClipboard.Call()
+
+	pngName, _ := syscall.UTF16PtrFromString("PNG")
+	if cfPNG, _, _ := procRegisterClipboardFormatW.Call(uintptr(unsafe.Pointer(pngName))); cfPNG != 0 {
+		if data, ok := clipboardData(cfPNG); ok {
+			if img, err := png.Decode(bytes.NewReader(data)); err == nil {
+				return img, nil
+			}
+		}
+	}
+
+	if data, ok := clipboardData(cfDIB); ok {
+		return decodeDIB(data)
+	}
+	return nil, nil
+}
+
+// openClipboard 打开剪贴板，其他程序占用时短暂重试
+func openClipboard() error {
+	for i := 0; i < openAttempts; i++ {
+		if ret, _, _ := procOpenClipboard.Call(0); ret != 0 {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return errors.New("剪贴板被其他程序占用")
+}
+
+// clipboardData 复制剪贴板中指定格式的数据
+func clipboardData(format uintptr) ([]byte, bool) {
+	if ret, _, _ := procIsClipboardFormatAvailable.Call(format); ret == 0 {
+		return nil, false
+	}
+	handle, _, _ := procGetClipboardData.Call(format)
+	if handle == 0 {
+		return nil, false
+	}
+	size, _, _ := procGlobalSize.Call(handle)
+	ptr, _, _ := procGlobalLock.Call(handle)
+	if ptr == 0 || size == 0 {
+		return nil, false
+	}
+	defer procGlobalUnlock.Call(handle)
+
+	// 由系统复制内存，避免将 uintptr 转换回 Go 指针
+	data := make([]byte, size)
+	procRtlMoveMemory.Call(uintptr(unsafe.Pointer(&data[0])), ptr, size)
+	return data, true
+}
+
+// decodeDIB 解码 CF_DIB 位图（BITMAPINFOHEADER + 像素数据），支持 24 位与 32 位
+func decodeDIB(data []byte) (image.Image, error) {
+	if len(data) < 40 {
+		return nil, errors.New("剪贴板位图数据不完整")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(data[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(data[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(data[8:12])))
+	bitCount := int(binary.LittleEndian.Uint16(data[14:16]))
+	compression := binary.LittleEndian.Uint32(data[16:20])
+
+	if bitCount != 24 && bitCount != 32 {
+		return nil, fmt.Errorf("不支持的剪贴板位图格式: %d 位", bitCount)
+	}
+	if compression != biRGB && compression != biBitfields {
+		return nil, fmt.Errorf("不支持的剪贴板位图压缩方式: %d", compression)
+	}
+
+	// 高度为正表示自下而上存储
+	bottomUp := height > 0
+	if height < 0 {
+		height = -height
+	}
+	if width <= 0 || height == 0 {
+		return nil, errors.New("剪贴板位图尺寸无效")
+	}
+
+	offset := headerSize
+	if compression == biBitfields && headerSize == 40 {
+		offset += 12 // 紧随信息头的三个颜色掩码
+	}
+	stride := (width*bitCount + 31) / 32 * 4
+	if len(data) < offset+stride*height {
+		return nil, errors.New("剪贴板位图数据不完整")
+	}
+
+	bytesPerPixel := bitCount / 8
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row := y
+		if bottomUp {
+			row = height - 1 - y
+		}
+		src := data[offset+row*stride:]
+		dst := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			p := src[x*bytesPerPixel:]
+			dst[x*4+0] = p[2]
+			dst[x*4+1] = p[1]
+			dst[x*4+2] = p[0]
+			dst[x*4+3] = 0xff // CF_DIB 的 alpha 通道通常无效，按不透明处理
+		}
+	}
+	return img, nil
+}
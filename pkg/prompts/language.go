@@ -0,0 +1,58 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 回答语言
+const (
+	LanguageChinese = "zh"
+	LanguageEnglish = "en"
+)
+
+// LanguageName 回答语言的显示名称，未设置时返回“跟随提示词”
+func LanguageName(lang string) string {
+	switch lang {
+	case LanguageChinese:
+		return "中文"
+	case LanguageEnglish:
+		return "English"
+	default:
+		return "跟随提示词"
+	}
+}
+
+// LanguageInstruction 回答语言与编程语言偏好，追加在 System Prompt 末尾，没有偏好时返回空字符串
+func LanguageInstruction(answerLanguage string, codeLanguages []string) string {
+	var lines []string
+	switch answerLanguage {
+	case LanguageChinese:
+		lines = append(lines, "- 使用简体中文回答，专有名词与代码标识符保留原文。")
+	case LanguageEnglish:
+		lines = append(lines, "- Answer in English, regardless of the language of the question or of the instructions above.")
+	}
+
+	var langs []string
+	for _, lang := range codeLanguages {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	if len(langs) > 0 {
+		lines = append(lines, fmt.Sprintf("- 需要编写代码时优先使用 %s（按先后顺序）；题目明确指定语言时以题目为准。", strings.Join(langs, "、")))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\n# 语言偏好\n" + strings.Join(lines, "\n")
+}
+
+// ReaskPrompt 切换回答语言后请求模型用新语言重新回答上一题
+func ReaskPrompt(answerLanguage string) string {
+	if answerLanguage == LanguageEnglish {
+		return "Please answer the previous question again in English."
+	}
+	return "请用简体中文重新回答上一个问题。"
+}
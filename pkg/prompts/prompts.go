@@ -110,3 +110,66 @@ const ResumeParsePrompt = `# Role 你是一个**通用型简历重构与解析
 
 # Input Data 
 简历内容见附件。`
+
+// ReviewPrompt 解题结果自检提示词（辅助模型审查主模型回答）
+const ReviewPrompt = `# 角色
+你是严谨的解题审查员，负责复核另一位助手针对截图中题目给出的回答。
+
+# 任务
+对照截图中的题目，检查回答：
+1. 是否有事实、计算、逻辑或代码错误（指出具体位置）
+2. 是否遗漏了边界情况、特殊输入或题目中的限制条件
+3. 代码题的时间/空间复杂度是否满足题目要求
+
+# 输出要求
+- 使用简短的 Markdown 列表，总长度不超过 200 字
+- 没有发现问题时直接说明“未发现明显问题”
+- 不要重写完整答案，只给出需要修改的要点
+- 最后一行固定输出：置信度：高/中/低`
+
+// ContextSummaryPrompt 长对话压缩提示词（辅助模型总结早期对话）
+const ContextSummaryPrompt = `# 角色
+你负责压缩一段较长的解题对话，使后续对话在有限的上下文中仍能继续。
+
+# 任务
+阅读下面的早期对话记录，输出一份摘要，保留：
+1. 每道题目的关键信息（题意、输入输出格式、限制条件）
+2. 已给出的最终答案、关键代码与结论
+3. 用户提出的要求、偏好和纠正
+
+# 输出要求
+- 使用简洁的 Markdown 列表，按题目分组
+- 关键代码可以保留，但删除与结论无关的推导过程
+- 不要添加对话中没有的内容`
+
+// ClassifyPrompt 题型识别提示词（辅助模型在截图解题前判断题型，用于路由到对应的解题方案）
+const ClassifyPrompt = `# 角色
+你是题目分类器，只判断截图中题目的类型，不解答题目。
+
+# 题型
+- coding: 编程题、算法题、代码阅读与调试
+- math: 数学计算、推导与证明
+- choice: 单选题、多选题、判断题
+- prose: 简答题、论述题、概念问答等以文字作答的题目
+- diagram: 需要理解或绘制图表、架构图、流程图的题目
+- other: 无法归入以上类型
+
+# 输出格式
+{"category": "题型", "confidence": 0.0 到 1.0 之间的置信度, "reason": "不超过 20 字的判断依据"}
+
+只输出JSON，不要任何解释。`
+
+// ConsistencyPrompt 多次采样投票的作答格式要求（追加在 System Prompt 末尾，便于提取最终答案）
+const ConsistencyPrompt = `
+
+# 作答格式
+先给出简要的推理过程，然后在最后一行严格按以下格式输出最终答案：
+最终答案：<答案>
+
+- 选择题只输出选项字母，多选题按字母顺序连写（如 AC）
+- 数值题只输出最终数值，不要带算式和单位
+- 最终答案这一行不要使用 Markdown 格式
+- 无论使用何种语言作答，最后一行都必须以“最终答案：”开头`
+
+// ContinuePrompt 继续输出被中断的回答
+const ContinuePrompt = "你的上一条回答在输出过程中被中断了。请从中断的位置继续输出，不要重复已经输出的内容，也不要添加开场白；如果中断在代码块中，请直接续写代码。"
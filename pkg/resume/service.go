@@ -98,8 +98,8 @@ func (s *Service) GetResumeBase64() (string, error) {
 	return encoded, nil
 }
 
-// ParseResume 解析简历为 Markdown
-func (s *Service) ParseResume(ctx context.Context, provider llm.Provider) (string, error) {
+// ParseResume 解析简历为 Markdown，language 为回答语言偏好，为空时按提示词输出
+func (s *Service) ParseResume(ctx context.Context, provider llm.Provider, language string) (string, error) {
 	// 1. Read Resume
 	resumeBase64, err := s.GetResumeBase64()
 	if err != nil {
@@ -112,7 +112,7 @@ func (s *Service) ParseResume(ctx context.Context, provider llm.Provider) (strin
 
 	// 2. 构建解析简历的消息
 	messages := []llm.Message{
-		llm.NewUserMessage(prompts.ResumeParsePrompt),
+		llm.NewUserMessage(prompts.ResumeParsePrompt + prompts.LanguageInstruction(language, nil)),
 		llm.NewMultiPartMessage(llm.RoleUser, []llm.ContentPart{
 			llm.PDFPart(resumeBase64),
 		}),
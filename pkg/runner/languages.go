@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"Q-Solver/pkg/codeblock"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// 支持的语言
+const (
+	LangPython = "python"
+	LangGo     = "go"
+	LangCpp    = "cpp"
+	LangJava   = "java"
+	LangJS     = "javascript"
+)
+
+// NormalizeLanguage 将代码块语言标记转换为支持的语言，不支持时返回空
+func NormalizeLanguage(lang string) string {
+	name := codeblock.Language(lang)
+	if _, ok := toolchains[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// toolchain 一种语言的编译与运行方式
+type toolchain struct {
+	tools []string // 依次查找的可执行文件，找到第一个即可
+
+	// sourceFile 根据代码决定源文件名
+	sourceFile func(code string) string
+	// compile 返回编译命令，解释型语言为 nil
+	compile func(tool, dir, src string) []string
+	// run 返回运行命令
+	run func(tool, dir, src string, memoryMB int) []string
+
+	// env 返回运行时附加的环境变量，可为 nil
+	env func(memoryMB int) []string
+
+	// rlimit 是否使用系统级内存限制
+	// Go、JVM 与 V8 启动时会预留大量虚拟地址空间，改用各自的内存参数
+	rlimit bool
+}
+
+// binaryName 编译产物文件名
+func binaryName() string {
+	if runtime.GOOS == "windows" {
+		return "main.exe"
+	}
+	return "main"
+}
+
+// javaClassPattern 匹配 Java 的 public class 名称
+var javaClassPattern = regexp.MustCompile(`public\s+(?:final\s+)?class\s+(\w+)`)
+
+var toolchains = map[string]toolchain{
+	LangPython: {
+		tools:      []string{"python3", "python", "py"},
+		sourceFile: func(string) string { return "main.py" },
+		run: func(tool, dir, src string, _ int) []string {
+			return []string{tool, src}
+		},
+		rlimit: true,
+	},
+	LangGo: {
+		tools:      []string{"go"},
+		sourceFile: func(string) string { return "main.go" },
+		compile: func(tool, dir, src string) []string {
+			return []string{tool, "build", "-o", filepath.Join(dir, binaryName()), src}
+		},
+		run: func(tool, dir, src string, _ int) []string {
+			return []string{filepath.Join(dir, binaryName())}
+		},
+		env: func(memoryMB int) []string {
+			if memoryMB <= 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("GOMEMLIMIT=%dMiB", memoryMB)}
+		},
+	},
+	LangCpp: {
+		tools:      []string{"g++", "clang++"},
+		sourceFile: func(string) string { return "main.cpp" },
+		compile: func(tool, dir, src string) []string {
+			return []string{tool, "-O2", "-std=c++17", "-o", filepath.Join(dir, binaryName()), src}
+		},
+		run: func(tool, dir, src string, _ int) []string {
+			return []string{filepath.Join(dir, binaryName())}
+		},
+		rlimit: true,
+	},
+	LangJava: {
+		tools: []string{"javac"},
+		sourceFile: func(code string) string {
+			if m := javaClassPattern.FindStringSubmatch(code); m != nil {
+				return m[1] + ".java"
+			}
+			return "Main.java"
+		},
+		compile: func(tool, dir, src string) []string {
+			return []string{tool, "-encoding", "UTF-8", "-d", dir, src}
+		},
+		run: func(tool, dir, src string, memoryMB int) []string {
+			// javac 与 java 位于同一目录
+			java := filepath.Join(filepath.Dir(tool), "java")
+			className := strings.TrimSuffix(filepath.Base(src), ".java")
+			args := []string{java}
+			if memoryMB > 0 {
+				args = append(args, fmt.Sprintf("-Xmx%dm", memoryMB))
+			}
+			return append(args, "-cp", dir, className)
+		},
+	},
+	LangJS: {
+		tools:      []string{"node"},
+		sourceFile: func(string) string { return "main.js" },
+		run: func(tool, dir, src string, memoryMB int) []string {
+			args := []string{tool}
+			if memoryMB > 0 {
+				args = append(args, fmt.Sprintf("--max-old-space-size=%d", memoryMB))
+			}
+			return append(args, src)
+		},
+	},
+}
+
+// findTool 查找语言的工具链，未安装时返回空
+func findTool(lang string) string {
+	tc, ok := toolchains[lang]
+	if !ok {
+		return ""
+	}
+	for _, name := range tc.tools {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// AvailableLanguages 返回当前机器已安装工具链的语言
+func AvailableLanguages() map[string]bool {
+	available := make(map[string]bool, len(toolchains))
+	for lang := range toolchains {
+		available[lang] = findTool(lang) != ""
+	}
+	return available
+}
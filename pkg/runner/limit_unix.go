@@ -0,0 +1,39 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// limitedCommand 创建带内存限制的命令（通过 ulimit -v 限制虚拟内存）
+func limitedCommand(ctx context.Context, args []string, memoryMB int) *exec.Cmd {
+	var cmd *exec.Cmd
+	if memoryMB > 0 {
+		// macOS 不支持 RLIMIT_AS，忽略设置失败
+		script := fmt.Sprintf(`ulimit -v %d 2>/dev/null; exec "$@"`, memoryMB*1024)
+		cmd = exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", script, "sh"}, args...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	}
+
+	// 使用独立进程组，超时时结束整组进程
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// startLimited 启动进程，内存限制已在 limitedCommand 中设置
+func startLimited(cmd *exec.Cmd, _ int) (func(), error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,119 @@
+//go:build windows
+
+package runner
+
+import (
+	"Q-Solver/pkg/logger"
+	"context"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// limitedCommand 创建命令（不弹出控制台窗口）
+// 进程以挂起状态创建，由 startLimited 加入 Job Object 后再恢复运行
+func limitedCommand(ctx context.Context, args []string, _ int) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: windows.CREATE_NO_WINDOW | windows.CREATE_SUSPENDED,
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// startLimited 启动挂起的进程，加入 Job Object 后恢复运行
+// 进程在加入 Job 前不会执行任何代码，内存限制从第一条指令开始生效，其创建的子进程也都属于该 Job
+// 返回的 release 关闭 Job，同时结束残留的子进程
+func startLimited(cmd *exec.Cmd, memoryMB int) (func(), error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	job, err := newJob(memoryMB)
+	if err != nil {
+		logger.Printf("[运行] 创建 Job Object 失败，不限制内存: %v", err)
+	} else if err := assignToJob(job, cmd.Process.Pid); err != nil {
+		logger.Printf("[运行] 加入 Job Object 失败: %v", err)
+	}
+	release := func() {
+		if job != 0 {
+			windows.CloseHandle(job)
+		}
+	}
+
+	if err := resumeProcess(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		release()
+		return nil, fmt.Errorf("恢复进程失败: %w", err)
+	}
+	return release, nil
+}
+
+// newJob 创建关闭时结束所有进程的 Job Object，memoryMB > 0 时限制单个进程的内存
+func newJob(memoryMB int) (windows.Handle, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if memoryMB > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY
+		info.ProcessMemoryLimit = uintptr(memoryMB) << 20
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+// assignToJob 将进程加入 Job Object
+func assignToJob(job windows.Handle, pid int) error {
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(process)
+	return windows.AssignProcessToJobObject(job, process)
+}
+
+// resumeProcess 恢复挂起进程的线程
+// exec.Cmd 不提供主线程句柄，通过线程快照找到属于该进程的线程
+func resumeProcess(pid int) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	resumed := 0
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != uint32(pid) {
+			continue
+		}
+		thread, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			return err
+		}
+		_, err = windows.ResumeThread(thread)
+		windows.CloseHandle(thread)
+		if err != nil {
+			return err
+		}
+		resumed++
+	}
+	if resumed == 0 {
+		return fmt.Errorf("未找到进程 %d 的线程", pid)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// Package runner 在本地编译并运行回答中的代码，用样例检查输出
+// 这不是沙箱：代码以当前用户权限运行，可以访问文件与网络，只限制运行时间与内存
+package runner
+
+import (
+	"Q-Solver/pkg/logger"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 运行限制默认值
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultMemoryMB = 256
+	compileTimeout  = 60 * time.Second
+	maxOutputBytes  = 1 << 20 // 单个样例最多保留 1MB 输出
+	maxDiffLines    = 20
+)
+
+// ErrUnsupportedLanguage 不支持的语言
+var ErrUnsupportedLanguage = errors.New("不支持运行该语言的代码")
+
+// TestCase 一组样例输入与期望输出
+type TestCase struct {
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+}
+
+// Limits 运行限制
+type Limits struct {
+	Timeout  time.Duration // 单个样例的超时
+	MemoryMB int           // 内存上限，0 表示不限制
+}
+
+// CaseResult 单个样例的运行结果
+type CaseResult struct {
+	Index      int    `json:"index"`
+	Passed     bool   `json:"passed"`
+	Input      string `json:"input"`
+	Expected   string `json:"expected"`
+	Actual     string `json:"actual"`
+	Stderr     string `json:"stderr,omitempty"`
+	ExitCode   int    `json:"exitCode"`
+	TimedOut   bool   `json:"timedOut,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Diff       string `json:"diff,omitempty"`
+}
+
+// Result 一次运行的结果
+type Result struct {
+	Language     string       `json:"language"`
+	Code         string       `json:"code"`
+	CompileError string       `json:"compileError,omitempty"`
+	Cases        []CaseResult `json:"cases"`
+	Passed       bool         `json:"passed"`
+}
+
+// Run 在临时目录中编译并逐个运行样例
+// 子进程没有沙箱隔离，以当前用户权限运行，仅限制运行时间与内存，工作目录为独立的临时目录
+func Run(ctx context.Context, language string, code string, tests []TestCase, limits Limits) (Result, error) {
+	lang := NormalizeLanguage(language)
+	if lang == "" {
+		return Result{}, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+	tool := findTool(lang)
+	if tool == "" {
+		return Result{}, fmt.Errorf("未找到 %s 的运行环境，请先安装", lang)
+	}
+	if limits.Timeout <= 0 {
+		limits.Timeout = DefaultTimeout
+	}
+
+	tc := toolchains[lang]
+	result := Result{Language: lang, Code: code, Cases: make([]CaseResult, 0, len(tests))}
+
+	dir, err := os.MkdirTemp("", "qsolver-run-*")
+	if err != nil {
+		return result, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, tc.sourceFile(code))
+	if err := os.WriteFile(src, []byte(code), 0644); err != nil {
+		return result, fmt.Errorf("写入源文件失败: %w", err)
+	}
+
+	// 编译
+	if tc.compile != nil {
+		compileArgs := tc.compile(tool, dir, src)
+		compileCtx, cancel := context.WithTimeout(ctx, compileTimeout)
+		out, err := exec.CommandContext(compileCtx, compileArgs[0], compileArgs[1:]...).CombinedOutput()
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.CompileError = strings.TrimSpace(string(out))
+			if result.CompileError == "" {
+				result.CompileError = err.Error()
+			}
+			logger.Printf("[运行] 编译失败: %v", err)
+			return result, nil
+		}
+	}
+
+	// 运行样例
+	args := tc.run(tool, dir, src, limits.MemoryMB)
+	var env []string
+	if tc.env != nil {
+		env = tc.env(limits.MemoryMB)
+	}
+	result.Passed = len(tests) > 0
+	for i, test := range tests {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		cr := runCase(ctx, args, env, dir, test, limits, tc.rlimit)
+		cr.Index = i
+		if !cr.Passed {
+			result.Passed = false
+		}
+		result.Cases = append(result.Cases, cr)
+	}
+
+	logger.Printf("[运行] %s 运行完成，通过 %d/%d", lang, countPassed(result.Cases), len(result.Cases))
+	return result, nil
+}
+
+// runCase 运行单个样例
+func runCase(ctx context.Context, args []string, env []string, dir string, test TestCase, limits Limits, rlimit bool) CaseResult {
+	cr := CaseResult{Input: test.Input, Expected: test.Expected}
+
+	caseCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	// Go、JVM 与 V8 通过各自的参数限制内存，不再叠加系统级限制
+	memoryMB := limits.MemoryMB
+	if !rlimit {
+		memoryMB = 0
+	}
+	cmd := limitedCommand(caseCtx, args, memoryMB)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdin = strings.NewReader(test.Input)
+
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxOutputBytes, maxOutputBytes
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	release, err := startLimited(cmd, memoryMB)
+	if err == nil {
+		err = cmd.Wait()
+		release()
+	}
+	cr.DurationMs = time.Since(start).Milliseconds()
+
+	cr.Actual = stdout.String()
+	cr.Stderr = strings.TrimSpace(stderr.String())
+	if cmd.ProcessState != nil {
+		cr.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if errors.Is(caseCtx.Err(), context.DeadlineExceeded) {
+		cr.TimedOut = true
+		cr.Diff = fmt.Sprintf("运行超时（%s）", limits.Timeout)
+		return cr
+	}
+	if err != nil {
+		if cr.Stderr == "" {
+			cr.Stderr = err.Error()
+		}
+		cr.Diff = fmt.Sprintf("运行出错（退出码 %d）", cr.ExitCode)
+		return cr
+	}
+
+	// 未提供期望输出时只检查能否正常运行
+	if strings.TrimSpace(test.Expected) == "" {
+		cr.Passed = true
+		return cr
+	}
+	cr.Diff = diffOutput(test.Expected, cr.Actual)
+	cr.Passed = cr.Diff == ""
+	return cr
+}
+
+// normalizeOutput 忽略行尾空白和末尾空行
+func normalizeOutput(s string) []string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOutput 逐行比较期望输出与实际输出，一致时返回空
+func diffOutput(expected, actual string) string {
+	exp := normalizeOutput(expected)
+	act := normalizeOutput(actual)
+
+	var b strings.Builder
+	shown := 0
+	for i := 0; i < max(len(exp), len(act)); i++ {
+		var e, a string
+		if i < len(exp) {
+			e = exp[i]
+		}
+		if i < len(act) {
+			a = act[i]
+		}
+		if e == a && i < len(exp) && i < len(act) {
+			continue
+		}
+		if shown == maxDiffLines {
+			b.WriteString("...\n")
+			break
+		}
+		fmt.Fprintf(&b, "第 %d 行:\n", i+1)
+		if i < len(exp) {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+		if i < len(act) {
+			fmt.Fprintf(&b, "+ %s\n", a)
+		}
+		shown++
+	}
+	return b.String()
+}
+
+func countPassed(cases []CaseResult) int {
+	n := 0
+	for _, c := range cases {
+		if c.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// limitedBuffer 超过上限后丢弃多余输出
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.Len(); remain < len(p) {
+		if remain > 0 {
+			b.Buffer.Write(p[:remain])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "\n...(输出过长已截断)"
+	}
+	return b.Buffer.String()
+}
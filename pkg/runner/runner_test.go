@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     string
+	}{
+		{"equal", "1 2\n3\n", "1 2\n3\n", ""},
+		{"trailing spaces and newlines", "1 2\n3", "1 2  \r\n3\n\n", ""},
+		{"changed line", "1\n2\n3", "1\n5\n3", "第 2 行:\n- 2\n+ 5\n"},
+		{"missing line", "1\n2", "1", "第 2 行:\n- 2\n"},
+		{"extra line", "1", "1\n2", "第 2 行:\n+ 2\n"},
+		{"extra empty line", "1\n\n2", "1\n2", "第 2 行:\n- \n+ 2\n第 3 行:\n- 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffOutput(tt.expected, tt.actual); got != tt.want {
+				t.Errorf("diffOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffOutputTruncates(t *testing.T) {
+	var expected, actual strings.Builder
+	for i := 0; i < maxDiffLines+5; i++ {
+		fmt.Fprintf(&expected, "%d\n", i)
+		fmt.Fprintf(&actual, "x%d\n", i)
+	}
+
+	got := diffOutput(expected.String(), actual.String())
+	if n := strings.Count(got, "行:\n"); n != maxDiffLines {
+		t.Errorf("shown %d lines, want %d", n, maxDiffLines)
+	}
+	if !strings.HasSuffix(got, "...\n") {
+		t.Errorf("diff does not end with ellipsis: %q", got[len(got)-20:])
+	}
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"Q-Solver/pkg/codeblock"
+	"fmt"
+	"strings"
+)
+
+// feedbackFieldLimit 反馈给模型的单个字段最大长度
+const feedbackFieldLimit = 2000
+
+// 样例标签关键字
+var (
+	inputLabels  = []string{"输入", "input", "stdin"}
+	outputLabels = []string{"输出", "output", "stdout", "期望"}
+)
+
+// ParseSamples 从回答中解析样例
+// 样例需写在代码块中，代码块上方一行标明“输入”或“输出”（如 "样例输入 1："、"Sample Output"）
+func ParseSamples(markdown string) []TestCase {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	tests := make([]TestCase, 0)
+
+	var pending *TestCase
+	for _, block := range codeblock.Extract(markdown) {
+		// 带编程语言标记的是代码而不是样例
+		if NormalizeLanguage(block.Language) != "" {
+			continue
+		}
+
+		switch labelOf(lines, block.StartLine-1) {
+		case "input":
+			pending = &TestCase{Input: ensureNewline(block.Code)}
+		case "output":
+			if pending != nil {
+				pending.Expected = block.Code
+				tests = append(tests, *pending)
+				pending = nil
+			}
+		}
+	}
+	return tests
+}
+
+// labelOf 查看代码块上方最近的非空行，判断是输入还是输出
+func labelOf(lines []string, fenceIdx int) string {
+	for i := fenceIdx - 1; i >= 0 && i >= fenceIdx-2; i-- {
+		label := strings.ToLower(strings.TrimSpace(lines[i]))
+		if label == "" {
+			continue
+		}
+		isInput := containsAny(label, inputLabels)
+		isOutput := containsAny(label, outputLabels)
+		switch {
+		case isInput && !isOutput:
+			return "input"
+		case isOutput && !isInput:
+			return "output"
+		}
+		return ""
+	}
+	return ""
+}
+
+// FeedbackPrompt 将失败的运行结果整理为追问内容，请模型修正代码
+func FeedbackPrompt(result Result) string {
+	var b strings.Builder
+	b.WriteString("你给出的代码在本地运行样例未通过，请根据以下结果分析原因，修正后给出完整代码。\n\n")
+
+	if result.CompileError != "" {
+		fmt.Fprintf(&b, "## 编译错误\n```\n%s\n```\n", clip(result.CompileError))
+		return b.String()
+	}
+
+	for _, c := range result.Cases {
+		if c.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "## 样例 %d\n", c.Index+1)
+		fmt.Fprintf(&b, "输入:\n```\n%s\n```\n", clip(strings.TrimRight(c.Input, "\n")))
+		fmt.Fprintf(&b, "期望输出:\n```\n%s\n```\n", clip(c.Expected))
+		fmt.Fprintf(&b, "实际输出:\n```\n%s\n```\n", clip(c.Actual))
+		if c.TimedOut {
+			b.WriteString("运行超时。\n")
+		}
+		if c.Stderr != "" {
+			fmt.Fprintf(&b, "错误输出:\n```\n%s\n```\n", clip(c.Stderr))
+		}
+		if c.Diff != "" && !c.TimedOut {
+			fmt.Fprintf(&b, "差异:\n```\n%s```\n", clip(c.Diff))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureNewline 标准输入以换行结尾，避免按行读取的程序阻塞
+func ensureNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
+
+// clip 截断过长的字段
+func clip(s string) string {
+	runes := []rune(s)
+	if len(runes) <= feedbackFieldLimit {
+		return s
+	}
+	return string(runes[:feedbackFieldLimit]) + "\n...(已截断)"
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []TestCase
+	}{
+		{
+			name:     "chinese labels",
+			markdown: "样例输入 1：\n```\n1 2\n```\n样例输出 1：\n```\n3\n```\n",
+			want:     []TestCase{{Input: "1 2\n", Expected: "3"}},
+		},
+		{
+			name:     "english labels with blank line",
+			markdown: "Sample Input\n\n```\n5\n```\n\nSample Output\n\n```\n25\n```",
+			want:     []TestCase{{Input: "5\n", Expected: "25"}},
+		},
+		{
+			name: "multiple samples",
+			markdown: "输入：\n```\na\n```\n输出：\n```\nA\n```\n" +
+				"输入：\n```\nb\n```\n输出：\n```\nB\n```\n",
+			want: []TestCase{{Input: "a\n", Expected: "A"}, {Input: "b\n", Expected: "B"}},
+		},
+		{
+			name:     "code block is not a sample",
+			markdown: "输入：\n```python\nprint(input())\n```\n输出：\n```\n1\n```\n",
+			want:     []TestCase{},
+		},
+		{
+			name:     "output without input",
+			markdown: "输出：\n```\n1\n```\n",
+			want:     []TestCase{},
+		},
+		{
+			name:     "unlabeled blocks",
+			markdown: "```\n1\n```\n```\n2\n```\n",
+			want:     []TestCase{},
+		},
+		{
+			name:     "ambiguous label",
+			markdown: "输入输出格式：\n```\n1\n```\n输出：\n```\n2\n```\n",
+			want:     []TestCase{},
+		},
+		{
+			name:     "label too far above",
+			markdown: "输入：\n\n\n```\n1\n```\n输出：\n```\n2\n```\n",
+			want:     []TestCase{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSamples(tt.markdown); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSamples() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
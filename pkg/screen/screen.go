@@ -2,11 +2,9 @@ package screen
 
 import (
 	imageutil "Q-Solver/pkg/ImageUtil"
-	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
-	"image/png"
+	"image"
 
 	"github.com/kbinani/screenshot"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -30,15 +28,26 @@ func (s *Service) Startup(ctx context.Context) {
 	s.ctx = ctx
 }
 
-// CapturePreview 获取当前截图的预览（Base64）
-func (s *Service) CapturePreview(quality int, sharpen float64, grayscale bool, noCompression bool, mode string) (PreviewResult, error) {
+// CapturePreview 获取当前截图的预览（Base64），region 仅在 "region" 模式下使用
+func (s *Service) CapturePreview(quality int, sharpen float64, grayscale bool, noCompression bool, mode string, region image.Rectangle) (PreviewResult, error) {
 	var x, y, w, h int
 
-	if mode == "fullscreen" {
+	switch mode {
+	case "fullscreen":
 		// 全屏模式：获取主屏幕尺寸
 		bounds := screenshot.GetDisplayBounds(0)
 		x, y, w, h = bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy()
-	} else {
+	case "region":
+		// 区域模式：截取配置的区域，只保留位于屏幕内的部分
+		if region.Empty() {
+			return PreviewResult{}, fmt.Errorf("未设置截图区域")
+		}
+		visible := region.Intersect(DesktopBounds())
+		if visible.Empty() {
+			return PreviewResult{}, fmt.Errorf("截图区域不在任何屏幕内")
+		}
+		x, y, w, h = visible.Min.X, visible.Min.Y, visible.Dx(), visible.Dy()
+	default:
 		// 窗口模式：获取当前窗口位置和大小
 		if s.ctx == nil {
 			return PreviewResult{}, fmt.Errorf("context not initialized")
@@ -54,22 +63,9 @@ func (s *Service) CapturePreview(quality int, sharpen float64, grayscale bool, n
 	}
 
 	// 处理图片
-	var imgBytes []byte
-	var ImageBase64 string
-	if noCompression {
-		var buf bytes.Buffer
-		err = png.Encode(&buf, img)
-		if err != nil {
-			return PreviewResult{}, fmt.Errorf("图片编码失败: %v", err)
-		}
-		imgBytes = buf.Bytes()
-		ImageBase64 = fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(imgBytes))
-	} else {
-		imgBytes, err = imageutil.CompressForOCR(img, quality, sharpen, grayscale)
-		if err != nil {
-			return PreviewResult{}, fmt.Errorf("图片处理失败: %v", err)
-		}
-		ImageBase64 = fmt.Sprintf("data:image/jpeg;base64,%s", base64.StdEncoding.EncodeToString(imgBytes))
+	imgBytes, ImageBase64, err := imageutil.Encode(img, quality, sharpen, grayscale, noCompression)
+	if err != nil {
+		return PreviewResult{}, err
 	}
 
 	// 计算大小
@@ -83,3 +79,55 @@ func (s *Service) CapturePreview(quality int, sharpen float64, grayscale bool, n
 		Size:     sizeStr,
 	}, nil
 }
+
+// WindowBounds 返回本窗口当前的位置和大小
+func (s *Service) WindowBounds() (image.Rectangle, error) {
+	if s.ctx == nil {
+		return image.Rectangle{}, fmt.Errorf("context not initialized")
+	}
+	x, y := runtime.WindowGetPosition(s.ctx)
+	w, h := runtime.WindowGetSize(s.ctx)
+	return image.Rect(x, y, x+w, y+h), nil
+}
+
+// DesktopBounds 返回所有屏幕组成的虚拟桌面范围
+func DesktopBounds() image.Rectangle {
+	var bounds image.Rectangle
+	for i := 0; i < screenshot.NumActiveDisplays(); i++ {
+		bounds = bounds.Union(screenshot.GetDisplayBounds(i))
+	}
+	return bounds
+}
+
+// RegionSelection 供前端框选截图区域的整个桌面截图
+// 前端在图片上框选后，按桌面范围与图片尺寸的比例换算回屏幕坐标
+type RegionSelection struct {
+	Base64 string `json:"base64"`
+	X      int    `json:"x"` // 虚拟桌面左上角的屏幕坐标
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// CaptureDesktop 截取所有屏幕组成的虚拟桌面，用于框选截图区域
+func (s *Service) CaptureDesktop() (RegionSelection, error) {
+	bounds := DesktopBounds()
+	if bounds.Empty() {
+		return RegionSelection{}, fmt.Errorf("没有可用的屏幕")
+	}
+	img, err := screenshot.CaptureRect(bounds)
+	if err != nil {
+		return RegionSelection{}, fmt.Errorf("截图失败: %v", err)
+	}
+	_, dataURL, err := imageutil.Encode(img, 80, 0, false, false)
+	if err != nil {
+		return RegionSelection{}, err
+	}
+	return RegionSelection{
+		Base64: dataURL,
+		X:      bounds.Min.X,
+		Y:      bounds.Min.Y,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}, nil
+}
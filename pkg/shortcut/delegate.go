@@ -3,6 +3,14 @@ package shortcut
 // ServiceDelegate 定义了 Shortcut Service 需要 App 配合做的事情
 type ServiceDelegate interface {
 	TriggerSolve()
+	SolveWithProfile(profileID string)
+	TriggerCompare()
+	AddBatchPage()
+	SolveBatch()
+	SolveClipboard()
+	SolveWithVoting()
+	ToggleAnswerLanguage()
+	Refine(actionID string)
 	ToggleVisibility()
 	ToggleClickThrough()
 	MoveWindow(dx, dy int)
@@ -5,8 +5,16 @@ import (
 	"fmt"
 	"maps"
 	"runtime"
+	"strings"
 )
 
+// ProfileActionPrefix 解题方案快捷键的 action 前缀
+// 带前缀的快捷键需要自定义按键，仅 Windows 支持；macOS 只注册预设快捷键
+const ProfileActionPrefix = "profile:"
+
+// RefineActionPrefix 改进动作快捷键的 action 前缀（仅 Windows）
+const RefineActionPrefix = "refine:"
+
 type Service struct {
 	manager  *Manager
 	delegate ServiceDelegate
@@ -71,6 +79,24 @@ func (s *Service) handleTrigger(action string) {
 	case "solve":
 		logger.Println("触发解题")
 		s.delegate.TriggerSolve()
+	case "compare":
+		logger.Println("触发多模型对比")
+		s.delegate.TriggerCompare()
+	case "add_page":
+		logger.Println("添加截图到待解题队列")
+		s.delegate.AddBatchPage()
+	case "solve_batch":
+		logger.Println("触发多页截图解题")
+		s.delegate.SolveBatch()
+	case "solve_clipboard":
+		logger.Println("触发剪贴板解题")
+		s.delegate.SolveClipboard()
+	case "solve_vote":
+		logger.Println("触发多次采样投票解题")
+		s.delegate.SolveWithVoting()
+	case "toggle_language":
+		logger.Println("切换回答语言")
+		s.delegate.ToggleAnswerLanguage()
 	case "toggle":
 		logger.Println("切换可见性")
 		s.delegate.ToggleVisibility()
@@ -89,6 +115,17 @@ func (s *Service) handleTrigger(action string) {
 		s.delegate.ScrollContent("up")
 	case "scroll_down":
 		s.delegate.ScrollContent("down")
+	default:
+		// 解题方案快捷键："profile:<方案 ID>"
+		if profileID, ok := strings.CutPrefix(action, ProfileActionPrefix); ok {
+			logger.Printf("使用解题方案解题: %s", profileID)
+			s.delegate.SolveWithProfile(profileID)
+		}
+		// 改进动作快捷键："refine:<动作 ID>"
+		if actionID, ok := strings.CutPrefix(action, RefineActionPrefix); ok {
+			logger.Printf("改进上一个回答: %s", actionID)
+			s.delegate.Refine(actionID)
+		}
 	}
 }
 
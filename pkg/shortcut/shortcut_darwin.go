@@ -22,13 +22,19 @@ type Manager struct {
 }
 
 // macOS 默认快捷键映射 - 使用 Command + 数字键
+// macOS 不支持自定义快捷键，"profile:" 与 "refine:" 前缀的绑定不会注册
 var macDefaultShortcuts = map[string]struct {
 	mods []hotkey.Modifier
 	key  hotkey.Key
 }{
-	"solve":        {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key1},
-	"toggle":       {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key2},
-	"clickthrough": {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key3},
+	"solve":           {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key1},
+	"toggle":          {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key2},
+	"clickthrough":    {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key3},
+	"add_page":        {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key4},
+	"solve_batch":     {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key5},
+	"solve_clipboard": {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key6},
+	"toggle_language": {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key7},
+	"solve_vote":      {[]hotkey.Modifier{hotkey.ModCmd}, hotkey.Key8},
 	// 方向键快捷键使用 Command + Option + 方向键
 	"move_up":    {[]hotkey.Modifier{hotkey.ModCmd, hotkey.ModOption}, hotkey.KeyUp},
 	"move_down":  {[]hotkey.Modifier{hotkey.ModCmd, hotkey.ModOption}, hotkey.KeyDown},
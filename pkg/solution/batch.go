@@ -0,0 +1,103 @@
+package solution
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxBatchPages 单次批量解题最多累积的截图数
+const MaxBatchPages = 10
+
+// ErrBatchFull 待解题截图已达上限
+var ErrBatchFull = fmt.Errorf("最多只能累积 %d 张截图", MaxBatchPages)
+
+// ErrBatchEmpty 没有待解题的截图
+var ErrBatchEmpty = errors.New("没有待解题的截图")
+
+// BatchPage 待解题的一页截图
+type BatchPage struct {
+	ID        string    `json:"id"`
+	Base64    string    `json:"-"`         // 完整截图，不发送给前端
+	Thumbnail string    `json:"thumbnail"` // 缩略图 data URL
+	Size      string    `json:"size"`
+	AddedAt   time.Time `json:"addedAt"`
+}
+
+// Batch 多页截图的待解题队列（内容超出一屏时分多次截图）
+type Batch struct {
+	mu    sync.Mutex
+	pages []BatchPage
+	seq   int
+}
+
+// NewBatch 创建待解题队列
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Add 追加一页截图，返回追加后的预览列表
+func (b *Batch) Add(page BatchPage) ([]BatchPage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pages) >= MaxBatchPages {
+		return nil, ErrBatchFull
+	}
+	b.seq++
+	page.ID = fmt.Sprintf("page-%d", b.seq)
+	if page.AddedAt.IsZero() {
+		page.AddedAt = time.Now()
+	}
+	b.pages = append(b.pages, page)
+	return b.snapshot(), nil
+}
+
+// Remove 移除指定截图
+func (b *Batch) Remove(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, page := range b.pages {
+		if page.ID == id {
+			b.pages = append(b.pages[:i], b.pages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pages 返回当前所有截图（按添加顺序）
+func (b *Batch) Pages() []BatchPage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.snapshot()
+}
+
+// Take 取出所有截图并清空队列
+func (b *Batch) Take() ([]BatchPage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pages) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	pages := b.pages
+	b.pages = nil
+	return pages, nil
+}
+
+// Clear 清空队列
+func (b *Batch) Clear() {
+	b.mu.Lock()
+	b.pages = nil
+	b.mu.Unlock()
+}
+
+// snapshot 复制当前列表（调用方需持有锁）
+func (b *Batch) snapshot() []BatchPage {
+	pages := make([]BatchPage, len(b.pages))
+	copy(pages, b.pages)
+	return pages
+}
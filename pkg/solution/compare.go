@@ -0,0 +1,213 @@
+package solution
+
+import (
+	"Q-Solver/pkg/common"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CompareTarget 参与对比的模型及其 Provider
+type CompareTarget struct {
+	config.CompareTarget
+	LLM llm.Provider
+}
+
+// CompareResult 单个模型的对比结果
+type CompareResult struct {
+	TargetID     string     `json:"targetId"`
+	Provider     string     `json:"provider,omitempty"`
+	Model        string     `json:"model"`
+	Content      string     `json:"content,omitempty"`
+	Thinking     string     `json:"thinking,omitempty"`
+	Usage        *llm.Usage `json:"usage,omitempty"`
+	FinishReason string     `json:"finishReason,omitempty"`
+	LatencyMs    int64      `json:"latencyMs"`
+	FirstChunkMs int64      `json:"firstChunkMs,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// CompareRun 一次对比的完整记录
+type CompareRun struct {
+	ID        string          `json:"id"`
+	StartedAt time.Time       `json:"startedAt"`
+	Results   []CompareResult `json:"results"`
+}
+
+// compareChunk 对比流式输出事件的数据
+type compareChunk struct {
+	TargetID string `json:"targetId"`
+	Content  string `json:"content"`
+}
+
+// Compare 将同一请求并行发送给多个模型，分别流式输出并记录耗时与用量
+// 对比不读写对话历史，每个模型都是全新对话
+func (s *Solver) Compare(ctx context.Context, req Request, targets []CompareTarget, cb Callbacks) CompareRun {
+	s.CancelReview()
+
+	emit := func(event string, data ...interface{}) {
+		if cb.EmitEvent != nil {
+			cb.EmitEvent(event, data...)
+		}
+	}
+
+	messages := []llm.Message{
+		llm.NewSystemMessage(buildSystemPrompt(req)),
+		buildUserMessage(req),
+	}
+
+	run := CompareRun{
+		ID:        fmt.Sprintf("compare-%d", time.Now().UnixNano()),
+		StartedAt: time.Now(),
+		Results:   make([]CompareResult, len(targets)),
+	}
+
+	logger.Printf("[对比] 开始对比 %d 个模型", len(targets))
+	started := make([]config.CompareTarget, 0, len(targets))
+	for _, t := range targets {
+		// 不向前端发送 API Key
+		started = append(started, config.CompareTarget{ID: t.ID, Provider: t.Provider, Model: t.Model})
+	}
+	emit("compare-start", started)
+
+	interval := time.Duration(req.Config.StreamFlushInterval) * time.Millisecond
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target CompareTarget) {
+			defer wg.Done()
+			result := compareOne(ctx, target, messages, interval, emit)
+			run.Results[i] = result
+			emit("compare-result", result)
+		}(i, target)
+	}
+	wg.Wait()
+
+	emit("compare-done", run)
+	return run
+}
+
+// compareOne 请求单个模型
+// 每个模型的流式输出经各自的 StreamEmitter 合并发送，返回前发送完缓冲内容，保证 compare-result 在最后一个片段之后
+func compareOne(ctx context.Context, target CompareTarget, messages []llm.Message, interval time.Duration, emit func(string, ...interface{})) CompareResult {
+	result := CompareResult{
+		TargetID: target.ID,
+		Provider: target.Provider,
+		Model:    target.Model,
+	}
+	if target.LLM == nil {
+		result.Error = "Provider 创建失败"
+		return result
+	}
+
+	stream := common.NewStreamEmitter(func(event string, data ...interface{}) {
+		text, _ := data[0].(string)
+		emit(event, compareChunk{TargetID: target.ID, Content: text})
+	}, interval, 0)
+
+	start := time.Now()
+	var firstChunkOnce sync.Once
+
+	response, err := target.LLM.GenerateContentStream(ctx, messages, func(chunk llm.StreamChunk) {
+		firstChunkOnce.Do(func() {
+			result.FirstChunkMs = time.Since(start).Milliseconds()
+		})
+		switch chunk.Type {
+		case llm.ChunkThinking:
+			stream.Append("compare-stream-thinking", chunk.Content)
+		case llm.ChunkContent:
+			stream.Append("compare-stream-chunk", chunk.Content)
+		}
+	})
+	result.LatencyMs = time.Since(start).Milliseconds()
+	stream.Close()
+
+	if err != nil {
+		logger.Printf("[对比] %s 请求失败: %v", target.ID, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Content = response.Content
+	result.Thinking = response.Thinking
+	result.Usage = response.Usage
+	result.FinishReason = response.FinishReason
+	logger.Printf("[对比] %s 完成，耗时 %dms", target.ID, result.LatencyMs)
+	return result
+}
+
+// CompareLog 对比记录的本地存储（JSON Lines，每行一次对比）
+type CompareLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewCompareLog 创建对比记录存储
+func NewCompareLog(dir string) *CompareLog {
+	return &CompareLog{path: filepath.Join(dir, "compare.jsonl")}
+}
+
+// Append 追加一次对比记录
+func (l *CompareLog) Append(run CompareRun) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// List 返回最近的对比记录（新的在前），limit <= 0 时返回全部
+func (l *CompareLog) List(limit int) ([]CompareRun, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CompareRun{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []CompareRun
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var run CompareRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			logger.Printf("[对比] 跳过无法解析的记录: %v", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 新的在前
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
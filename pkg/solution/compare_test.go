@@ -0,0 +1,67 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chunkProvider 逐块流式返回 chunks 的 Provider
+type chunkProvider struct {
+	fakeProvider
+	chunks []string
+}
+
+func (p *chunkProvider) GenerateContentStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	for _, c := range p.chunks {
+		onChunk(llm.StreamChunk{Type: llm.ChunkContent, Content: c})
+	}
+	return llm.NewAssistantMessage(strings.Join(p.chunks, "")), nil
+}
+
+func TestCompareFlushesBeforeResult(t *testing.T) {
+	targets := []CompareTarget{
+		{CompareTarget: config.CompareTarget{ID: "a"}, LLM: &chunkProvider{chunks: []string{"a1", "a2", "a3"}}},
+		{CompareTarget: config.CompareTarget{ID: "b"}, LLM: &chunkProvider{chunks: []string{"b1", "b2"}}},
+	}
+
+	var (
+		mu       sync.Mutex
+		streamed = map[string]string{}
+		results  = map[string]string{}
+	)
+	cb := Callbacks{EmitEvent: func(event string, data ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch event {
+		case "compare-stream-chunk":
+			chunk := data[0].(compareChunk)
+			if _, done := results[chunk.TargetID]; done {
+				t.Errorf("chunk for %s after compare-result", chunk.TargetID)
+			}
+			streamed[chunk.TargetID] += chunk.Content
+		case "compare-result":
+			result := data[0].(CompareResult)
+			results[result.TargetID] = result.Content
+		}
+	}}
+
+	// 间隔足够长，片段只能在 compare-result 前由 Close 发送
+	cfg := config.NewDefaultConfig()
+	cfg.StreamFlushInterval = int(time.Hour / time.Millisecond)
+	s := NewSolver(nil)
+	run := s.Compare(context.Background(), Request{Config: cfg}, targets, cb)
+
+	if len(run.Results) != 2 {
+		t.Fatalf("results = %d, want 2", len(run.Results))
+	}
+	for id, content := range results {
+		if streamed[id] != content {
+			t.Errorf("target %s streamed %q, result %q", id, streamed[id], content)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// 上下文压缩策略
+const (
+	StrategyDropImages    = "drop_images"
+	StrategySummarize     = "summarize"
+	StrategySlidingWindow = "sliding_window"
+)
+
+// Token 估算参数，各家分词方式不同，只求量级准确
+const (
+	messageOverheadTokens = 4    // 每条消息的格式开销
+	imageTokens           = 1500 // 单张截图的上限（大图会被模型服务缩小），尺寸未知时也按此计算
+	minImageTokens        = 85   // 单张截图的下限
+	pixelsPerImageToken   = 750  // 按像素数折算
+	minPDFTokens          = 1500 // 单个 PDF 至少按一页计算
+	pdfBytesPerToken      = 50   // PDF 按原始大小折算
+	summaryKeepTurns      = 2    // 总结时保留原文的最近轮数
+)
+
+// 省略截图后的占位文字，以及总结后插入的消息
+const (
+	droppedImageText    = "[截图已省略]"
+	droppedPDFText      = "[附件已省略]"
+	summaryUserPrefix   = "以下是此前对话的摘要，请在此基础上继续：\n\n"
+	summaryAssistantAck = "好的，我已了解此前的对话内容。"
+)
+
+// ContextReport 一次上下文压缩的结果（context-compacted 事件）
+type ContextReport struct {
+	Strategy      string `json:"strategy"`
+	Budget        int    `json:"budget"`
+	Before        int    `json:"before"` // 压缩前的估算 Token 数
+	After         int    `json:"after"`
+	DroppedImages int    `json:"droppedImages,omitempty"`
+	DroppedTurns  int    `json:"droppedTurns,omitempty"`
+	Summarized    int    `json:"summarized,omitempty"` // 被总结的轮数
+}
+
+// EstimateTokens 估算单条消息的 Token 数
+func EstimateTokens(msg llm.Message) int {
+	n := messageOverheadTokens + estimateText(msg.Content)
+	for _, part := range msg.Parts {
+		switch part.Type {
+		case llm.ContentText:
+			n += estimateText(part.Text)
+		case llm.ContentImage:
+			n += estimateImage(part.Base64)
+		case llm.ContentPDF:
+			_, data := llm.ParseBase64DataURL(part.Base64)
+			n += max(minPDFTokens, len(data)*3/4/pdfBytesPerToken)
+		}
+	}
+	return n
+}
+
+// EstimateMessagesTokens 估算消息列表的 Token 数
+func EstimateMessagesTokens(messages []llm.Message) int {
+	n := 0
+	for _, msg := range messages {
+		n += EstimateTokens(msg)
+	}
+	return n
+}
+
+// estimateImage 按图片尺寸估算 Token 数，只解析图片头，不解码像素
+func estimateImage(dataURL string) int {
+	_, data := llm.ParseBase64DataURL(dataURL)
+	cfg, _, err := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	if err != nil {
+		return imageTokens
+	}
+	return min(max(cfg.Width*cfg.Height/pixelsPerImageToken, minImageTokens), imageTokens)
+}
+
+// estimateText 中日韩文字约每字一个 Token，其余字符约四个一个 Token
+func estimateText(s string) int {
+	wide, narrow := 0, 0
+	for _, r := range s {
+		if r < 0x2E80 {
+			narrow++
+		} else {
+			wide++
+		}
+	}
+	return wide + (narrow+3)/4
+}
+
+// fitContext 历史消息超出预算时按配置的策略压缩，未超出时原样返回 nil 报告
+// history 为包含 System Prompt 的历史消息，current 为本轮将要发送的用户消息
+// summarizer 为总结早期对话使用的 Provider（按其自身配置的模型生成）
+func fitContext(ctx context.Context, cfg config.Config, summarizer llm.Provider, history []llm.Message, current llm.Message) ([]llm.Message, *ContextReport) {
+	budget := cfg.ContextBudget
+	if budget <= 0 {
+		return history, nil
+	}
+	before := EstimateMessagesTokens(history) + EstimateTokens(current)
+	if before <= budget {
+		return history, nil
+	}
+
+	strategy := cfg.ContextStrategy
+	if strategy == "" {
+		strategy = StrategyDropImages
+	}
+	report := &ContextReport{Strategy: strategy, Budget: budget, Before: before}
+	// 本轮消息必须发送，预算只用于历史部分
+	limit := budget - EstimateTokens(current)
+
+	// 先复制一份，压缩不影响调用方持有的切片
+	messages := append([]llm.Message(nil), history...)
+
+	if strategy == StrategySummarize {
+		summarized, n, err := summarizeOlder(ctx, summarizer, messages)
+		if err != nil {
+			if ctx.Err() != nil {
+				return messages, nil
+			}
+			logger.Printf("[上下文] 总结早期对话失败，改为省略旧截图: %v", err)
+		} else {
+			messages, report.Summarized = summarized, n
+		}
+	}
+	if strategy != StrategySlidingWindow && EstimateMessagesTokens(messages) > limit {
+		messages, report.DroppedImages = dropImages(messages, limit)
+	}
+	// 其他策略仍不足以满足预算时，最后按滑动窗口丢弃最早的问答
+	if EstimateMessagesTokens(messages) > limit {
+		messages, report.DroppedTurns = slideWindow(messages, limit)
+	}
+
+	report.After = EstimateMessagesTokens(messages) + EstimateTokens(current)
+	logger.Printf("[上下文] %s: 估算 %d -> %d Token（预算 %d）", strategy, report.Before, report.After, budget)
+	return messages, report
+}
+
+// dropImages 从最早的消息开始将截图和附件替换为占位文字，直到满足预算
+func dropImages(messages []llm.Message, limit int) ([]llm.Message, int) {
+	dropped := 0
+	total := EstimateMessagesTokens(messages)
+	for i := range messages {
+		if total <= limit {
+			break
+		}
+		msg := messages[i]
+		if msg.Role != llm.RoleUser || len(msg.Parts) == 0 {
+			continue
+		}
+
+		parts := make([]llm.ContentPart, 0, len(msg.Parts))
+		changed := false
+		for _, part := range msg.Parts {
+			switch part.Type {
+			case llm.ContentImage:
+				parts = append(parts, llm.TextPart(droppedImageText))
+				dropped++
+				changed = true
+			case llm.ContentPDF:
+				parts = append(parts, llm.TextPart(droppedPDFText))
+				changed = true
+			default:
+				parts = append(parts, part)
+			}
+		}
+		if !changed {
+			continue
+		}
+		before := EstimateTokens(msg)
+		msg.Parts = parts
+		messages[i] = msg
+		total += EstimateTokens(msg) - before
+	}
+	return messages, dropped
+}
+
+// slideWindow 从最早的问答开始丢弃，直到满足预算，System Prompt 始终保留
+func slideWindow(messages []llm.Message, limit int) ([]llm.Message, int) {
+	start := 0
+	for start < len(messages) && messages[start].Role == llm.RoleSystem {
+		start++
+	}
+
+	turns := 0
+	for EstimateMessagesTokens(messages) > limit && start < len(messages) {
+		// 一轮为一条用户消息及其后的助手回答
+		end := start + 1
+		for end < len(messages) && messages[end].Role == llm.RoleAssistant {
+			end++
+		}
+		messages = append(messages[:start], messages[end:]...)
+		turns++
+	}
+	return messages, turns
+}
+
+// summarizeOlder 用辅助模型将最近几轮之前的对话总结为一轮摘要，返回被总结的轮数
+func summarizeOlder(ctx context.Context, provider llm.Provider, messages []llm.Message) ([]llm.Message, int, error) {
+	start := 0
+	for start < len(messages) && messages[start].Role == llm.RoleSystem {
+		start++
+	}
+
+	// 找到需要保留原文的最近几轮的起点
+	keepFrom := len(messages)
+	kept := 0
+	for i := len(messages) - 1; i >= start; i-- {
+		if messages[i].Role == llm.RoleUser {
+			kept++
+			keepFrom = i
+			if kept == summaryKeepTurns {
+				break
+			}
+		}
+	}
+	if kept < summaryKeepTurns || keepFrom <= start {
+		return messages, 0, fmt.Errorf("对话轮数不足，无需总结")
+	}
+
+	older := messages[start:keepFrom]
+	var transcript strings.Builder
+	turns := 0
+	for _, msg := range older {
+		switch msg.Role {
+		case llm.RoleUser:
+			turns++
+			transcript.WriteString("## 用户\n")
+		case llm.RoleAssistant:
+			transcript.WriteString("## 助手\n")
+		default:
+			continue
+		}
+		transcript.WriteString(messageText(msg))
+		transcript.WriteString("\n\n")
+	}
+
+	response, err := provider.GenerateContent(ctx, "", []llm.Message{
+		llm.NewSystemMessage(prompts.ContextSummaryPrompt),
+		llm.NewUserMessage(transcript.String()),
+	})
+	if err != nil {
+		return messages, 0, err
+	}
+	if strings.TrimSpace(response.Content) == "" {
+		return messages, 0, fmt.Errorf("辅助模型返回内容为空")
+	}
+
+	result := make([]llm.Message, 0, start+2+len(messages)-keepFrom)
+	result = append(result, messages[:start]...)
+	result = append(result,
+		llm.NewUserMessage(summaryUserPrefix+strings.TrimSpace(response.Content)),
+		llm.NewAssistantMessage(summaryAssistantAck),
+	)
+	result = append(result, messages[keepFrom:]...)
+	return result, turns, nil
+}
+
+// messageText 提取消息中的文字，截图与附件以占位文字表示
+func messageText(msg llm.Message) string {
+	var parts []string
+	if msg.Content != "" {
+		parts = append(parts, msg.Content)
+	}
+	for _, part := range msg.Parts {
+		switch part.Type {
+		case llm.ContentText:
+			parts = append(parts, part.Text)
+		case llm.ContentImage:
+			parts = append(parts, "[截图]")
+		case llm.ContentPDF:
+			parts = append(parts, "[附件]")
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// compactHistory 缩小早期截图、历史超出预算时压缩，并写回对话，之后的请求直接基于处理后的历史
+func (s *Solver) compactHistory(ctx context.Context, req Request, provider llm.Provider, conv *Conversation, history []llm.Message, current llm.Message, cb Callbacks) []llm.Message {
+	// 总结使用辅助模型自己的 Provider，未配置辅助模型时由主模型总结
+	s.mu.RLock()
+	summarizer := s.assistantProvider
+	s.mu.RUnlock()
+	if summarizer == nil {
+		summarizer = provider
+	}
+
+	shrunk, imageReport := shrinkHistoryImages(history, req.Config.HistoryImagePolicy, req.Config.HistoryImageKeep)
+	compacted, report := fitContext(ctx, req.Config, summarizer, shrunk, current)
+	if imageReport == nil && report == nil {
+		return history
+	}
+
+	s.mu.Lock()
+	// 处理期间对话未被其他请求修改时才写回
+	if len(conv.messages) == len(history) {
+		conv.messages = append([]llm.Message(nil), compacted...)
+	}
+	s.mu.Unlock()
+
+	if cb.EmitEvent != nil {
+		if imageReport != nil {
+			cb.EmitEvent("history-images-compacted", imageReport)
+		}
+		if report != nil {
+			cb.EmitEvent("context-compacted", report)
+		}
+	}
+	return compacted
+}
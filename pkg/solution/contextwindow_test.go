@@ -0,0 +1,229 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 无法解析的图片按 imageTokens 计算，便于构造确定的 Token 数
+const testImage = "data:image/png;base64,AAAA"
+
+// fakeProvider 返回固定内容或错误的 Provider
+type fakeProvider struct {
+	content string
+	err     error
+	calls   int
+}
+
+func (p *fakeProvider) GenerateContentStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	return p.GenerateContent(ctx, "", messages)
+}
+
+func (p *fakeProvider) GenerateContent(ctx context.Context, model string, messages []llm.Message) (llm.Message, error) {
+	p.calls++
+	if err := ctx.Err(); err != nil {
+		return llm.Message{}, err
+	}
+	if p.err != nil {
+		return llm.Message{}, p.err
+	}
+	return llm.NewAssistantMessage(p.content), nil
+}
+
+func (p *fakeProvider) GetModels(ctx context.Context) ([]string, error) { return nil, nil }
+func (p *fakeProvider) TestChat(ctx context.Context) error              { return nil }
+
+// testHistory 构造 System Prompt 加 turns 轮带截图的问答
+func testHistory(turns int) []llm.Message {
+	messages := []llm.Message{llm.NewSystemMessage("system")}
+	for i := 0; i < turns; i++ {
+		messages = append(messages,
+			llm.NewMultiPartMessage(llm.RoleUser, []llm.ContentPart{llm.ImagePart(testImage), llm.TextPart("question")}),
+			llm.NewAssistantMessage("answer"),
+		)
+	}
+	return messages
+}
+
+func countImages(messages []llm.Message) int {
+	n := 0
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type == llm.ContentImage {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestFitContextWithinBudget(t *testing.T) {
+	history := testHistory(3)
+	current := llm.NewUserMessage("next")
+
+	tests := []struct {
+		name   string
+		budget int
+	}{
+		{"disabled", 0},
+		{"enough", 100000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{ContextBudget: tt.budget}
+			got, report := fitContext(context.Background(), cfg, nil, history, current)
+			if report != nil {
+				t.Errorf("report = %+v, want nil", report)
+			}
+			if len(got) != len(history) {
+				t.Errorf("len = %d, want %d", len(got), len(history))
+			}
+		})
+	}
+}
+
+func TestFitContextDropImages(t *testing.T) {
+	history := testHistory(3)
+	current := llm.NewUserMessage("next")
+	// 预算只够保留一张截图
+	budget := EstimateMessagesTokens(history) + EstimateTokens(current) - 2*imageTokens + 50
+	cfg := config.Config{ContextBudget: budget, ContextStrategy: StrategyDropImages}
+
+	got, report := fitContext(context.Background(), cfg, nil, history, current)
+	if report == nil {
+		t.Fatal("report = nil, want compaction")
+	}
+	if report.DroppedImages != 2 || report.DroppedTurns != 0 {
+		t.Errorf("dropped images %d turns %d, want 2 and 0", report.DroppedImages, report.DroppedTurns)
+	}
+	if report.After > budget {
+		t.Errorf("After = %d, exceeds budget %d", report.After, budget)
+	}
+	// 保留最近一轮的截图
+	if got[5].Parts[0].Type != llm.ContentImage {
+		t.Error("latest screenshot was dropped")
+	}
+	if got[1].Parts[0].Text != droppedImageText {
+		t.Errorf("oldest screenshot = %+v, want placeholder", got[1].Parts[0])
+	}
+	if countImages(history) != 3 {
+		t.Error("fitContext modified the caller's history")
+	}
+}
+
+func TestFitContextSlidingWindow(t *testing.T) {
+	history := testHistory(3)
+	current := llm.NewUserMessage("next")
+	// 预算只够保留一轮问答
+	turnTokens := EstimateTokens(history[1]) + EstimateTokens(history[2])
+	budget := EstimateTokens(history[0]) + turnTokens + EstimateTokens(current)
+	cfg := config.Config{ContextBudget: budget, ContextStrategy: StrategySlidingWindow}
+
+	got, report := fitContext(context.Background(), cfg, nil, history, current)
+	if report == nil {
+		t.Fatal("report = nil, want compaction")
+	}
+	if report.DroppedTurns != 2 || report.DroppedImages != 0 {
+		t.Errorf("dropped turns %d images %d, want 2 and 0", report.DroppedTurns, report.DroppedImages)
+	}
+	if len(got) != 3 || got[0].Role != llm.RoleSystem {
+		t.Errorf("got %d messages starting with %q, want system prompt plus one turn", len(got), got[0].Role)
+	}
+}
+
+func TestFitContextSummarize(t *testing.T) {
+	current := llm.NewUserMessage("next")
+
+	t.Run("summarized", func(t *testing.T) {
+		history := testHistory(4)
+		provider := &fakeProvider{content: "summary"}
+		cfg := config.Config{ContextBudget: EstimateMessagesTokens(history) - 1, ContextStrategy: StrategySummarize}
+
+		got, report := fitContext(context.Background(), cfg, provider, history, current)
+		if report == nil || report.Summarized != 2 {
+			t.Fatalf("report = %+v, want 2 summarized turns", report)
+		}
+		if !strings.HasSuffix(got[1].Content, "summary") {
+			t.Errorf("summary message = %q", got[1].Content)
+		}
+		// System Prompt + 摘要问答 + 保留的两轮
+		if len(got) != 1+2+2*summaryKeepTurns {
+			t.Errorf("len = %d, want %d", len(got), 1+2+2*summaryKeepTurns)
+		}
+	})
+
+	t.Run("failed falls back to dropping images", func(t *testing.T) {
+		history := testHistory(4)
+		provider := &fakeProvider{err: errors.New("boom")}
+		cfg := config.Config{ContextBudget: EstimateMessagesTokens(history) - 1, ContextStrategy: StrategySummarize}
+
+		_, report := fitContext(context.Background(), cfg, provider, history, current)
+		if report == nil || report.Summarized != 0 || report.DroppedImages == 0 {
+			t.Errorf("report = %+v, want dropped images without summary", report)
+		}
+	})
+
+	t.Run("cancelled keeps messages", func(t *testing.T) {
+		history := testHistory(4)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cfg := config.Config{ContextBudget: EstimateMessagesTokens(history) - 1, ContextStrategy: StrategySummarize}
+
+		got, report := fitContext(ctx, cfg, &fakeProvider{content: "summary"}, history, current)
+		if report != nil {
+			t.Errorf("report = %+v, want nil", report)
+		}
+		if len(got) != len(history) {
+			t.Errorf("len = %d, want %d", len(got), len(history))
+		}
+	})
+}
+
+func TestDropImages(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		wantDropped int
+	}{
+		{"within limit", 1 << 20, 0},
+		{"drop all", 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dropped := dropImages(testHistory(3), tt.limit)
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+			if countImages(got) != 3-tt.wantDropped {
+				t.Errorf("images left = %d, want %d", countImages(got), 3-tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestSlideWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		wantTurns int
+		wantLen   int
+	}{
+		{"within limit", 1 << 20, 0, 7},
+		{"keeps system prompt", 0, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, turns := slideWindow(testHistory(3), tt.limit)
+			if turns != tt.wantTurns || len(got) != tt.wantLen {
+				t.Errorf("turns %d len %d, want %d and %d", turns, len(got), tt.wantTurns, tt.wantLen)
+			}
+			if got[0].Role != llm.RoleSystem {
+				t.Errorf("first message role = %q, want system", got[0].Role)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package solution
+
+import (
+	"Q-Solver/pkg/codeblock"
+	"Q-Solver/pkg/history"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrConversationNotFound 对话不存在或已关闭
+var ErrConversationNotFound = errors.New("对话不存在")
+
+// defaultConversationTitle 尚未产生回答的对话标题
+const defaultConversationTitle = "新对话"
+
+// Conversation 一个独立的对话线程
+// 消息历史、持久化记录与最近一次回答的代码块都归属于对话，由 Solver 的锁保护
+type Conversation struct {
+	id          string
+	createdAt   time.Time
+	updatedAt   time.Time
+	messages    []llm.Message
+	record      *history.Conversation // 对话的持久化记录，尚未保存时为 nil
+	codeBlocks  []codeblock.Block     // 最近一次回答中的代码块
+	interrupted bool                  // 最近一次回答被中断，可继续输出
+}
+
+// ConversationInfo 对话概要（供前端展示与切换）
+type ConversationInfo struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	RecordID    string    `json:"recordId,omitempty"` // 对应的解题记录 ID
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	TurnCount   int       `json:"turnCount"`
+	Active      bool      `json:"active"`
+	Interrupted bool      `json:"interrupted,omitempty"` // 最近一次回答被中断
+}
+
+func newConversation(id string) *Conversation {
+	now := time.Now()
+	return &Conversation{
+		id:        id,
+		createdAt: now,
+		updatedAt: now,
+		messages:  make([]llm.Message, 0),
+	}
+}
+
+// info 生成对话概要
+func (c *Conversation) info(active bool) ConversationInfo {
+	info := ConversationInfo{
+		ID:          c.id,
+		Title:       defaultConversationTitle,
+		CreatedAt:   c.createdAt,
+		UpdatedAt:   c.updatedAt,
+		Active:      active,
+		Interrupted: c.interrupted,
+	}
+	for _, msg := range c.messages {
+		if msg.Role == llm.RoleUser {
+			info.TurnCount++
+		}
+	}
+	if c.record != nil {
+		info.RecordID = c.record.ID
+		if c.record.Title != "" {
+			info.Title = c.record.Title
+		}
+	}
+	return info
+}
+
+// reset 清空对话内容，对话本身保留
+func (c *Conversation) reset() {
+	c.messages = make([]llm.Message, 0)
+	c.record = nil
+	c.codeBlocks = nil
+	c.interrupted = false
+	c.updatedAt = time.Now()
+}
+
+// fork 复制出一条新对话，消息与解题记录各自独立
+func (c *Conversation) fork(id string) *Conversation {
+	forked := newConversation(id)
+	forked.messages = append(forked.messages, c.messages...)
+	forked.codeBlocks = append([]codeblock.Block(nil), c.codeBlocks...)
+	forked.interrupted = c.interrupted
+	if c.record != nil {
+		forked.record = c.record.Fork()
+	}
+	return forked
+}
+
+// snapshot 返回消息历史的副本，请求期间无需持有锁
+func (c *Conversation) snapshot() []llm.Message {
+	return append([]llm.Message(nil), c.messages...)
+}
+
+// ensureSystemPrompt 确保消息历史的第一条是正确的 System Prompt
+func (c *Conversation) ensureSystemPrompt(prompt string) {
+	if len(c.messages) == 0 {
+		c.messages = append(c.messages, llm.NewSystemMessage(prompt))
+		logger.Println("插入 SystemPrompt")
+		return
+	}
+
+	// 检查第一条是否为系统消息
+	if c.messages[0].Role == llm.RoleSystem {
+		if c.messages[0].Content != prompt {
+			c.messages[0] = llm.NewSystemMessage(prompt)
+			logger.Println("替换 SystemPrompt")
+		}
+	} else {
+		// 第一条不是系统消息，插入到头部
+		c.messages = append([]llm.Message{llm.NewSystemMessage(prompt)}, c.messages...)
+		logger.Println("插入 SystemPrompt 到消息历史头部")
+	}
+}
+
+// commitTurn 将本轮问答追加到对话，newThread 为 true 时以本轮重新开始
+func (c *Conversation) commitTurn(systemPrompt string, userMsg llm.Message, answer string, newThread bool) {
+	if newThread {
+		c.messages = []llm.Message{llm.NewSystemMessage(systemPrompt)}
+	}
+	c.messages = append(c.messages, userMsg, llm.NewAssistantMessage(answer))
+	c.updatedAt = time.Now()
+}
+
+// hasUserTurn 对话中是否已有用户消息
+func (c *Conversation) hasUserTurn() bool {
+	for _, msg := range c.messages {
+		if msg.Role == llm.RoleUser {
+			return true
+		}
+	}
+	return false
+}
+
+// lastAnswer 对话中最近一次回答
+func (c *Conversation) lastAnswer() string {
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if c.messages[i].Role == llm.RoleAssistant {
+			return c.messages[i].Content
+		}
+	}
+	return ""
+}
+
+// recordTurn 将本轮问答追加到解题记录并返回待保存的副本，newThread 为 true 时另起一条记录
+// 调用方需持有写锁，副本在释放锁后保存
+func (c *Conversation) recordTurn(systemPrompt string, turn history.Turn, newThread bool) *history.Conversation {
+	if c.record == nil || newThread {
+		c.record = history.NewConversation(systemPrompt)
+	}
+	c.record.SystemPrompt = systemPrompt
+	c.record.AddTurn(turn)
+
+	snapshot := *c.record
+	snapshot.Turns = slices.Clone(c.record.Turns)
+	return &snapshot
+}
+
+// ==================== Solver 对话管理 ====================
+
+// nextConversationID 生成对话 ID，调用方需持有写锁
+func (s *Solver) nextConversationID() string {
+	s.seq++
+	return fmt.Sprintf("conv-%d", s.seq)
+}
+
+// addConversation 加入对话并设为当前对话，调用方需持有写锁
+func (s *Solver) addConversation(conv *Conversation) {
+	s.conversations[conv.id] = conv
+	s.order = append(s.order, conv.id)
+	s.activeID = conv.id
+}
+
+// conversation 按 ID 查找对话，ID 为空时返回当前对话，调用方需持有锁
+func (s *Solver) conversation(id string) (*Conversation, error) {
+	if id == "" {
+		id = s.activeID
+	}
+	conv, ok := s.conversations[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	}
+	return conv, nil
+}
+
+// CreateConversation 新建空白对话并切换到该对话
+func (s *Solver) CreateConversation() ConversationInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv := newConversation(s.nextConversationID())
+	s.addConversation(conv)
+	logger.Printf("[对话] 新建对话: %s", conv.id)
+	return conv.info(true)
+}
+
+// SwitchConversation 切换当前对话
+func (s *Solver) SwitchConversation(id string) (ConversationInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, err := s.conversation(id)
+	if err != nil {
+		return ConversationInfo{}, err
+	}
+	s.activeID = conv.id
+	return conv.info(true), nil
+}
+
+// ForkConversation 复制对话为新分支并切换到新分支，ID 为空时复制当前对话
+func (s *Solver) ForkConversation(id string) (ConversationInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, err := s.conversation(id)
+	if err != nil {
+		return ConversationInfo{}, err
+	}
+	forked := conv.fork(s.nextConversationID())
+	s.addConversation(forked)
+	logger.Printf("[对话] 从 %s 分支出对话: %s", conv.id, forked.id)
+	return forked.info(true), nil
+}
+
+// CloseConversation 关闭对话（解题记录保留），关闭当前对话时切换到最近的一个
+// 最后一个对话被关闭时自动新建空白对话，保证始终存在当前对话
+func (s *Solver) CloseConversation(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, err := s.conversation(id)
+	if err != nil {
+		return err
+	}
+	delete(s.conversations, conv.id)
+	for i, cid := range s.order {
+		if cid == conv.id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	if s.activeID == conv.id {
+		if n := len(s.order); n > 0 {
+			s.activeID = s.order[n-1]
+		} else {
+			s.addConversation(newConversation(s.nextConversationID()))
+		}
+	}
+	logger.Printf("[对话] 关闭对话: %s", conv.id)
+	return nil
+}
+
+// ListConversations 按创建顺序列出所有打开的对话
+func (s *Solver) ListConversations() []ConversationInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]ConversationInfo, 0, len(s.order))
+	for _, id := range s.order {
+		list = append(list, s.conversations[id].info(id == s.activeID))
+	}
+	return list
+}
+
+// ActiveConversationID 当前对话的 ID
+func (s *Solver) ActiveConversationID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.activeID
+}
@@ -0,0 +1,161 @@
+package solution
+
+import (
+	imageutil "Q-Solver/pkg/ImageUtil"
+	"Q-Solver/pkg/codeblock"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// 附件类型
+const (
+	AttachmentImage = "image"
+	AttachmentPDF   = "pdf"
+	AttachmentText  = "text"
+)
+
+// MaxAttachments 单次最多发送的文件数
+const MaxAttachments = 10
+
+// ErrNoAttachments 没有可发送的文件
+var ErrNoAttachments = errors.New("没有可发送的文件")
+
+// Attachment 一个待发送给模型的文件
+type Attachment struct {
+	Name    string          `json:"name"`
+	Kind    string          `json:"kind"`
+	Size    int64           `json:"size"`              // 发送的字节数（图片为压缩后大小）
+	Preview string          `json:"preview,omitempty"` // 图片的 data URL，供前端显示
+	Part    llm.ContentPart `json:"-"`
+}
+
+// imageExtensions 按截图设置压缩后以图片发送的扩展名
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+}
+
+// LoadAttachments 读取文件并按类型转换为消息内容块，超过提供商大小上限时返回错误
+// 图片按截图设置压缩，PDF 原样发送，Markdown 与源文件作为文本发送
+func LoadAttachments(paths []string, cfg config.Config) ([]Attachment, error) {
+	if len(paths) == 0 {
+		return nil, ErrNoAttachments
+	}
+	if len(paths) > MaxAttachments {
+		return nil, fmt.Errorf("一次最多发送 %d 个文件", MaxAttachments)
+	}
+
+	limits := llm.LimitsFor(llm.DetectProviderType(cfg.Provider))
+	attachments := make([]Attachment, 0, len(paths))
+	var total int64
+	for _, path := range paths {
+		att, err := loadAttachment(path, cfg, limits)
+		if err != nil {
+			return nil, err
+		}
+		total += att.Size
+		if total > limits.Total {
+			return nil, fmt.Errorf("文件合计超过当前模型的大小上限 %s", formatBytes(limits.Total))
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments, nil
+}
+
+// loadAttachment 读取单个文件
+func loadAttachment(path string, cfg config.Config, limits llm.AttachmentLimits) (Attachment, error) {
+	name := filepath.Base(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("读取 %s 失败: %w", name, err)
+	}
+	if info.IsDir() {
+		return Attachment{}, fmt.Errorf("%s 是目录，请选择文件", name)
+	}
+	// 读取前先按最大上限拦截过大的文件
+	if info.Size() > max(limits.Image*4, limits.PDF, limits.Text) {
+		return Attachment{}, fmt.Errorf("%s 过大 (%s)", name, formatBytes(info.Size()))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("读取 %s 失败: %w", name, err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case imageExtensions[ext]:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Attachment{}, fmt.Errorf("%s 不是有效的图片: %w", name, err)
+		}
+		encoded, dataURL, err := imageutil.Encode(img, cfg.CompressionQuality, cfg.Sharpening, cfg.Grayscale, cfg.NoCompression)
+		if err != nil {
+			return Attachment{}, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := checkLimit(name, int64(len(encoded)), limits.Image); err != nil {
+			return Attachment{}, err
+		}
+		return Attachment{Name: name, Kind: AttachmentImage, Size: int64(len(encoded)), Preview: dataURL, Part: llm.ImagePart(dataURL)}, nil
+
+	case ext == ".pdf":
+		if err := checkLimit(name, int64(len(data)), limits.PDF); err != nil {
+			return Attachment{}, err
+		}
+		return Attachment{Name: name, Kind: AttachmentPDF, Size: int64(len(data)), Part: llm.PDFPart(base64.StdEncoding.EncodeToString(data))}, nil
+
+	default:
+		if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			return Attachment{}, fmt.Errorf("不支持的文件类型: %s", name)
+		}
+		if err := checkLimit(name, int64(len(data)), limits.Text); err != nil {
+			return Attachment{}, err
+		}
+		return Attachment{Name: name, Kind: AttachmentText, Size: int64(len(data)), Part: llm.TextPart(textFileContent(name, ext, string(data)))}, nil
+	}
+}
+
+// textFileContent 文本文件的发送格式：Markdown 原样附上，其余文件放入代码块
+func textFileContent(name, ext, content string) string {
+	content = strings.TrimRight(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if ext == ".md" || ext == ".markdown" || ext == ".txt" {
+		return fmt.Sprintf("# 文件：%s\n\n%s", name, content)
+	}
+
+	// 内容本身包含代码块时加长围栏，避免提前闭合
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("# 文件：%s\n\n%s%s\n%s\n%s", name, fence, codeblock.LanguageForExtension(ext), content, fence)
+}
+
+// checkLimit 检查单个文件是否超过上限
+func checkLimit(name string, size, limit int64) error {
+	if size > limit {
+		return fmt.Errorf("%s 超过当前模型的大小上限 (%s > %s)", name, formatBytes(size), formatBytes(limit))
+	}
+	return nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,133 @@
+package solution
+
+import (
+	imageutil "Q-Solver/pkg/ImageUtil"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"strings"
+)
+
+// 历史截图策略
+const (
+	ImagePolicyKeep        = "keep"
+	ImagePolicyThumbnail   = "thumbnail"
+	ImagePolicyPlaceholder = "placeholder"
+)
+
+// 历史截图缩小参数
+const (
+	historyThumbnailSize  = 512 // 缩略图长边像素
+	imageDescriptionRunes = 200 // 文字占位附带的回答摘要长度
+)
+
+// ImageReport 一次历史截图瘦身的结果（history-images-compacted 事件）
+type ImageReport struct {
+	Policy      string `json:"policy"`
+	Images      int    `json:"images"`      // 被缩小或替换的截图数
+	BytesBefore int    `json:"bytesBefore"` // 处理前这些截图的 data URL 总字节数
+	BytesAfter  int    `json:"bytesAfter"`
+	BytesSaved  int    `json:"bytesSaved"`
+}
+
+// shrinkHistoryImages 保留最近 keep 张截图的原图，更早的截图按策略替换为缩略图或文字占位
+// 已经是缩略图的截图不再处理；未设置策略时保留原图；没有需要处理的截图时返回 nil 报告
+func shrinkHistoryImages(messages []llm.Message, policy string, keep int) ([]llm.Message, *ImageReport) {
+	if policy == "" || policy == ImagePolicyKeep {
+		return messages, nil
+	}
+
+	report := &ImageReport{Policy: policy}
+	var result []llm.Message
+	seen := 0
+	// 从最新的消息往前数，超过 keep 张之后的截图才处理
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != llm.RoleUser {
+			continue
+		}
+
+		var parts []llm.ContentPart
+		changed := false
+		for j := len(msg.Parts) - 1; j >= 0; j-- {
+			part := msg.Parts[j]
+			if part.Type != llm.ContentImage {
+				continue
+			}
+			seen++
+			if seen <= keep {
+				continue
+			}
+
+			replacement, ok := shrinkImage(part, policy, answerAfter(messages, i))
+			if !ok {
+				continue
+			}
+			if !changed {
+				parts = append([]llm.ContentPart(nil), msg.Parts...)
+				changed = true
+			}
+			parts[j] = replacement
+			report.Images++
+			report.BytesBefore += len(part.Base64)
+			report.BytesAfter += len(replacement.Base64) + len(replacement.Text)
+		}
+		if !changed {
+			continue
+		}
+
+		if result == nil {
+			result = append([]llm.Message(nil), messages...)
+		}
+		msg.Parts = parts
+		result[i] = msg
+	}
+
+	if report.Images == 0 {
+		return messages, nil
+	}
+	report.BytesSaved = report.BytesBefore - report.BytesAfter
+	logger.Printf("[上下文] 历史截图 %s: %d 张，节省 %s", policy, report.Images, formatBytes(int64(report.BytesSaved)))
+	return result, report
+}
+
+// shrinkImage 按策略处理单张截图，已是缩略图时返回 false
+func shrinkImage(part llm.ContentPart, policy, answer string) (llm.ContentPart, bool) {
+	_, data := llm.ParseBase64DataURL(part.Base64)
+	raw, err := base64.StdEncoding.DecodeString(data)
+
+	if policy == ImagePolicyThumbnail && err == nil {
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(raw)); err == nil && max(cfg.Width, cfg.Height) <= historyThumbnailSize {
+			return part, false
+		}
+		if thumb, err := imageutil.Thumbnail(raw, historyThumbnailSize); err == nil {
+			return llm.ImagePart("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(thumb)), true
+		}
+	}
+
+	// 文字占位，或截图无法解码时退化为文字占位
+	text := "[早期截图已省略]"
+	if answer != "" {
+		runes := []rune(strings.TrimSpace(answer))
+		if len(runes) > imageDescriptionRunes {
+			runes = append(runes[:imageDescriptionRunes], '…')
+		}
+		text += " 当时的回答摘要：" + string(runes)
+	}
+	return llm.TextPart(text), true
+}
+
+// answerAfter 返回第 i 条消息之后的第一条助手回答，作为该轮截图内容的描述
+func answerAfter(messages []llm.Message, i int) string {
+	for _, msg := range messages[i+1:] {
+		switch msg.Role {
+		case llm.RoleAssistant:
+			return msg.Content
+		case llm.RoleUser:
+			return ""
+		}
+	}
+	return ""
+}
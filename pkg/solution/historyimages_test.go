@@ -0,0 +1,154 @@
+package solution
+
+import (
+	"Q-Solver/pkg/llm"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// pngDataURL 生成指定尺寸的 PNG data URL
+func pngDataURL(t *testing.T, width, height int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// imageHistory 构造每轮一张截图的问答，images 依次为各轮截图
+func imageHistory(answer string, images ...string) []llm.Message {
+	messages := []llm.Message{llm.NewSystemMessage("system")}
+	for _, img := range images {
+		messages = append(messages,
+			llm.NewMultiPartMessage(llm.RoleUser, []llm.ContentPart{llm.TextPart("q"), llm.ImagePart(img)}),
+			llm.NewAssistantMessage(answer),
+		)
+	}
+	return messages
+}
+
+func TestShrinkHistoryImages(t *testing.T) {
+	large := pngDataURL(t, 1024, 768)
+	small := pngDataURL(t, 64, 64)
+	longAnswer := strings.Repeat("答", imageDescriptionRunes+50)
+
+	tests := []struct {
+		name       string
+		messages   []llm.Message
+		policy     string
+		keep       int
+		wantImages int // 处理的截图数，0 表示不处理
+		check      func(t *testing.T, got []llm.Message)
+	}{
+		{
+			name:     "empty policy keeps",
+			messages: imageHistory("a", testImage, testImage),
+			policy:   "",
+		},
+		{
+			name:     "keep policy",
+			messages: imageHistory("a", testImage, testImage),
+			policy:   ImagePolicyKeep,
+		},
+		{
+			name:     "within keep count",
+			messages: imageHistory("a", testImage, testImage),
+			policy:   ImagePolicyPlaceholder,
+			keep:     2,
+		},
+		{
+			name:       "placeholder with answer summary",
+			messages:   imageHistory("答案是 42", testImage, testImage, testImage),
+			policy:     ImagePolicyPlaceholder,
+			keep:       1,
+			wantImages: 2,
+			check: func(t *testing.T, got []llm.Message) {
+				if part := got[1].Parts[1]; part.Type != llm.ContentText || !strings.Contains(part.Text, "答案是 42") {
+					t.Errorf("oldest screenshot = %+v, want placeholder with answer", part)
+				}
+				if got[5].Parts[1].Type != llm.ContentImage {
+					t.Error("latest screenshot was replaced")
+				}
+			},
+		},
+		{
+			name:       "placeholder truncates long answer",
+			messages:   imageHistory(longAnswer, testImage, testImage),
+			policy:     ImagePolicyPlaceholder,
+			keep:       1,
+			wantImages: 1,
+			check: func(t *testing.T, got []llm.Message) {
+				if text := got[1].Parts[1].Text; !strings.HasSuffix(text, "…") || strings.Contains(text, longAnswer) {
+					t.Errorf("placeholder was not truncated: %d bytes", len(text))
+				}
+			},
+		},
+		{
+			name:       "thumbnail",
+			messages:   imageHistory("a", large, large),
+			policy:     ImagePolicyThumbnail,
+			keep:       1,
+			wantImages: 1,
+			check: func(t *testing.T, got []llm.Message) {
+				part := got[1].Parts[1]
+				if part.Type != llm.ContentImage || !strings.HasPrefix(part.Base64, "data:image/jpeg;base64,") {
+					t.Fatalf("oldest screenshot = %.40s, want jpeg thumbnail", part.Base64)
+				}
+				_, data := llm.ParseBase64DataURL(part.Base64)
+				raw, _ := base64.StdEncoding.DecodeString(data)
+				cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+				if err != nil || max(cfg.Width, cfg.Height) > historyThumbnailSize {
+					t.Errorf("thumbnail %dx%d (%v), want long side <= %d", cfg.Width, cfg.Height, err, historyThumbnailSize)
+				}
+			},
+		},
+		{
+			name:     "thumbnail skips small images",
+			messages: imageHistory("a", small, small),
+			policy:   ImagePolicyThumbnail,
+			keep:     1,
+		},
+		{
+			name:       "thumbnail falls back to placeholder",
+			messages:   imageHistory("a", testImage, testImage),
+			policy:     ImagePolicyThumbnail,
+			keep:       1,
+			wantImages: 1,
+			check: func(t *testing.T, got []llm.Message) {
+				if got[1].Parts[1].Type != llm.ContentText {
+					t.Errorf("undecodable screenshot = %+v, want placeholder", got[1].Parts[1])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := countImages(tt.messages)
+			got, report := shrinkHistoryImages(tt.messages, tt.policy, tt.keep)
+
+			if tt.wantImages == 0 {
+				if report != nil {
+					t.Errorf("report = %+v, want nil", report)
+				}
+				return
+			}
+			if report == nil || report.Images != tt.wantImages {
+				t.Fatalf("report = %+v, want %d images", report, tt.wantImages)
+			}
+			if report.BytesSaved != report.BytesBefore-report.BytesAfter {
+				t.Errorf("BytesSaved = %d, want %d", report.BytesSaved, report.BytesBefore-report.BytesAfter)
+			}
+			if countImages(tt.messages) != before {
+				t.Error("shrinkHistoryImages modified the caller's messages")
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
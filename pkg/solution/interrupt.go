@@ -0,0 +1,64 @@
+package solution
+
+import (
+	"Q-Solver/pkg/history"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"errors"
+)
+
+// ErrNothingToContinue 对话的最近一次回答没有被中断
+var ErrNothingToContinue = errors.New("当前对话没有被中断的回答")
+
+// keepInterrupted 任务被取消时保留已输出的内容，标记为中断后写入对话与解题记录
+// 不是取消或尚未输出任何内容时返回 false，由调用方按原流程处理
+func (s *Solver) keepInterrupted(ctx context.Context, err error, conv *Conversation, systemPrompt string, turn history.Turn, newThread bool, cb Callbacks) bool {
+	if err == nil || !errors.Is(ctx.Err(), context.Canceled) {
+		return false
+	}
+	if turn.Answer == "" && turn.Thinking == "" {
+		return false
+	}
+
+	logger.Printf("当前任务已中断，保留已输出的内容 (%d 字节)", len(turn.Answer))
+	turn.Interrupted = true
+	s.commit(conv, systemPrompt, turn.User, turn, newThread, cb)
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution-interrupted", map[string]string{
+			"answer":   turn.Answer,
+			"thinking": turn.Thinking,
+		})
+	}
+	return true
+}
+
+// HasInterrupted 当前对话的最近一次回答是否被中断
+func (s *Solver) HasInterrupted() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conv, err := s.conversation("")
+	return err == nil && conv.interrupted
+}
+
+// Continue 请求模型从被中断的位置继续输出，作为新的一轮追加到对话
+func (s *Solver) Continue(ctx context.Context, req Request, cb Callbacks) bool {
+	s.mu.RLock()
+	interrupted := false
+	if conv, err := s.conversation(req.ConversationID); err == nil {
+		interrupted = conv.interrupted
+	}
+	s.mu.RUnlock()
+
+	if !interrupted {
+		if cb.EmitEvent != nil {
+			cb.EmitEvent("solution-error", ErrNothingToContinue.Error())
+		}
+		return false
+	}
+
+	logger.Println("[解题] 继续输出被中断的回答")
+	req.Text = prompts.ContinuePrompt
+	req.ScreenshotBase64, req.Screenshots, req.Attachments = "", nil, nil
+	return s.Ask(ctx, req, cb)
+}
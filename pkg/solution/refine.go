@@ -0,0 +1,46 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNothingToRefine 对话中还没有可改进的回答
+var ErrNothingToRefine = errors.New("当前对话还没有可改进的回答")
+
+// RefinePrompt 按改进动作的模板生成追问文字
+func RefinePrompt(action config.RefineAction, answer string, cfg config.Config) string {
+	language := "题目使用的编程语言"
+	if len(cfg.CodeLanguages) > 0 && strings.TrimSpace(cfg.CodeLanguages[0]) != "" {
+		language = strings.TrimSpace(cfg.CodeLanguages[0])
+	}
+	return strings.NewReplacer(
+		"{{answer}}", answer,
+		"{{language}}", language,
+	).Replace(action.Prompt)
+}
+
+// Refine 以改进动作追问对话的上一个回答，不附带新截图
+func (s *Solver) Refine(ctx context.Context, req Request, action config.RefineAction, cb Callbacks) bool {
+	s.mu.RLock()
+	answer := ""
+	if conv, err := s.conversation(req.ConversationID); err == nil {
+		answer = conv.lastAnswer()
+	}
+	s.mu.RUnlock()
+
+	if answer == "" {
+		if cb.EmitEvent != nil {
+			cb.EmitEvent("solution-error", ErrNothingToRefine.Error())
+		}
+		return false
+	}
+
+	logger.Printf("[改进] %s", action.Name)
+	req.Text = RefinePrompt(action, answer, req.Config)
+	req.ScreenshotBase64, req.Screenshots, req.Attachments = "", nil, nil
+	return s.Ask(ctx, req, cb)
+}
@@ -0,0 +1,85 @@
+package solution
+
+import (
+	"Q-Solver/pkg/history"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
+	"context"
+)
+
+// SetAssistantProvider 设置自检与上下文总结使用的辅助模型，传 nil 表示未配置
+func (s *Solver) SetAssistantProvider(provider llm.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assistantProvider = provider
+}
+
+// CancelReview 取消正在进行的自检，避免旧回答的审查结果出现在新问题中
+func (s *Solver) CancelReview() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reviewCancel != nil {
+		s.reviewCancel()
+		s.reviewCancel = nil
+	}
+}
+
+// startReview 主回答完成后在后台请求辅助模型审查，不阻塞主流程
+// 自检有独立的取消函数，主任务结束后仍可继续，下一次解题、追问或取消任务时随之取消
+func (s *Solver) startReview(ctx context.Context, req Request, userMsg llm.Message, answer string, cb Callbacks) {
+	s.mu.RLock()
+	provider := s.assistantProvider
+	s.mu.RUnlock()
+	if !req.Config.SelfReview || provider == nil || answer == "" {
+		return
+	}
+
+	s.mu.Lock()
+	if s.reviewCancel != nil {
+		s.reviewCancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.reviewCancel = cancel
+	s.mu.Unlock()
+
+	emit := func(event string, data ...interface{}) {
+		if cb.EmitEvent != nil {
+			cb.EmitEvent(event, data...)
+		}
+	}
+
+	// 题目截图与待审查的回答一起发送，简历附件与审查无关
+	parts := history.StripResume(userMsg.Parts)
+	if userMsg.Content != "" {
+		parts = append(parts, llm.TextPart(userMsg.Content))
+	}
+	parts = append(parts, llm.TextPart("# 待审查的回答\n\n"+answer))
+
+	messages := []llm.Message{
+		llm.NewSystemMessage(prompts.ReviewPrompt),
+		llm.NewMultiPartMessage(llm.RoleUser, parts),
+	}
+
+	go func() {
+		defer cancel()
+		logger.Printf("[自检] 使用辅助模型审查回答: %s", req.Config.AssistantModel)
+		emit("solution-review-start", req.Config.AssistantModel)
+
+		stream := newStreamEmitter(req.Config, cb)
+		response, err := provider.GenerateContentStream(ctx, messages, func(chunk llm.StreamChunk) {
+			if chunk.Type == llm.ChunkContent {
+				stream.Append("solution-review-chunk", chunk.Content)
+			}
+		})
+		stream.Close()
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Printf("[自检] 请求失败: %v", err)
+				emit("solution-review-error", err.Error())
+			}
+			return
+		}
+		emit("solution-review", response.Content)
+	}()
+}
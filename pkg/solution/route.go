@@ -0,0 +1,101 @@
+package solution
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/history"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 自动路由参数
+const (
+	routeTimeout       = 15 * time.Second // 识别超时后沿用当前解题方案
+	routeMinConfidence = 0.5              // 置信度低于该值时只记录结果，不切换方案
+)
+
+// classification 辅助模型返回的题型识别结果
+type classification struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// Route 使用辅助模型识别截图的题型，并选择 Category 对应的解题方案
+// cfg 为未应用解题方案的主配置；没有对应方案或置信度过低时 ProfileID 为空，沿用当前方案
+func (s *Solver) Route(ctx context.Context, cfg config.Config, screenshot string, cb Callbacks) (*history.Routing, error) {
+	s.mu.RLock()
+	provider := s.llmProvider
+	s.mu.RUnlock()
+	if provider == nil {
+		return nil, errors.New("模型未初始化")
+	}
+
+	model := cfg.AssistantModel
+	if model == "" {
+		model = cfg.Model
+	}
+	logger.Printf("[路由] 使用辅助模型识别题型: %s", model)
+
+	start := time.Now()
+	routeCtx, cancel := context.WithTimeout(ctx, routeTimeout)
+	defer cancel()
+	response, err := provider.GenerateContent(routeCtx, cfg.AssistantModel, []llm.Message{
+		llm.NewSystemMessage(prompts.ClassifyPrompt),
+		llm.NewMultiPartMessage(llm.RoleUser, []llm.ContentPart{llm.ImagePart(screenshot)}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseClassification(response.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	routing := &history.Routing{
+		Category:   result.Category,
+		Confidence: result.Confidence,
+		Reason:     result.Reason,
+		Model:      model,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if result.Confidence >= routeMinConfidence {
+		if profile, ok := cfg.ProfileForCategory(result.Category); ok {
+			routing.ProfileID = profile.ID
+			routing.ProfileName = profile.Name
+		}
+	}
+
+	logger.Printf("[路由] 题型: %s (置信度 %.2f, %dms)，解题方案: %q", routing.Category, routing.Confidence, routing.DurationMs, routing.ProfileName)
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution-route", routing)
+	}
+	return routing, nil
+}
+
+// parseClassification 解析辅助模型的回复，兼容代码块包裹与前后多余文字
+func parseClassification(content string) (classification, error) {
+	var result classification
+	begin := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if begin < 0 || end < begin {
+		return result, fmt.Errorf("无法解析题型识别结果: %s", content)
+	}
+	if err := json.Unmarshal([]byte(content[begin:end+1]), &result); err != nil {
+		return result, fmt.Errorf("无法解析题型识别结果: %w", err)
+	}
+
+	result.Category = strings.ToLower(strings.TrimSpace(result.Category))
+	if !slices.Contains(config.Categories, result.Category) {
+		result.Category = config.CategoryOther
+	}
+	result.Confidence = min(max(result.Confidence, 0), 1)
+	return result, nil
+}
@@ -1,12 +1,20 @@
 package solution
 
 import (
+	"Q-Solver/pkg/codeblock"
+	"Q-Solver/pkg/common"
 	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/history"
 	"Q-Solver/pkg/llm"
 	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Callbacks struct {
@@ -15,31 +23,107 @@ type Callbacks struct {
 
 type Request struct {
 	Config           config.Config
+	Provider         llm.Provider // 解题方案覆盖模型或参数时使用的 Provider，为 nil 时使用 Solver 当前 Provider
 	ScreenshotBase64 string
+	Screenshots      []string // 多页截图（按顺序），非空时替代 ScreenshotBase64
 	ResumeBase64     string
+	Text             string            // 追问文字（Ask 使用）
+	Attachments      []llm.ContentPart // 拖入或选择的文件，位于截图之后
+	ConversationID   string            // 目标对话，为空时使用当前对话
+	Routing          *history.Routing  // 自动路由的识别结果，随本轮写入解题记录
 }
 
 type Solver struct {
-	llmProvider llm.Provider
-	chatHistory []llm.Message // 改用统一的 Message 类型
+	mu                sync.RWMutex
+	llmProvider       llm.Provider
+	fastProvider      llm.Provider       // 快慢双模型中的快速模型，未启用时为 nil
+	assistantProvider llm.Provider       // 辅助模型（自检、上下文总结），未配置时为 nil
+	reviewCancel      context.CancelFunc // 取消正在进行的自检，没有自检时为 nil
+	history           *history.Store     // 解题记录存储，为 nil 时不持久化
+
+	saveMu     sync.Mutex     // 串行保存解题记录，不占用 mu
+	savedTurns map[string]int // 各记录已保存的轮数，避免较旧的副本覆盖较新的
+
+	conversations map[string]*Conversation // 打开的对话
+	order         []string                 // 对话的创建顺序
+	activeID      string                   // 当前对话
+	seq           int                      // 对话 ID 计数
 }
 
 func NewSolver(provider llm.Provider) *Solver {
-	return &Solver{
-		llmProvider: provider,
-		chatHistory: make([]llm.Message, 0),
+	s := &Solver{
+		llmProvider:   provider,
+		conversations: make(map[string]*Conversation),
+		savedTurns:    make(map[string]int),
 	}
+	s.addConversation(newConversation(s.nextConversationID()))
+	return s
 }
 
 func (s *Solver) SetProvider(provider llm.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.llmProvider = provider
 }
 
+// SetFastProvider 设置快慢双模型中的快速模型，传 nil 关闭
+func (s *Solver) SetFastProvider(provider llm.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fastProvider = provider
+}
+
+// SetHistoryStore 设置解题记录存储
+func (s *Solver) SetHistoryStore(store *history.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = store
+}
+
+// ClearHistory 清空当前对话
 func (s *Solver) ClearHistory() {
-	s.chatHistory = make([]llm.Message, 0)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conv, err := s.conversation(""); err == nil {
+		conv.reset()
+	}
+}
+
+// LoadConversation 将解题记录作为对话打开并切换到该对话，已打开时直接切换
+func (s *Solver) LoadConversation(record *history.Conversation) ConversationInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.order {
+		if conv := s.conversations[id]; conv.record != nil && conv.record.ID == record.ID {
+			s.activeID = id
+			return conv.info(true)
+		}
+	}
+	conv := newConversation(s.nextConversationID())
+	conv.messages = record.Messages()
+	conv.record = record
+	if n := len(record.Turns); n > 0 {
+		conv.interrupted = record.Turns[n-1].Interrupted
+	}
+	s.addConversation(conv)
+	return conv.info(true)
+}
+
+// DetachConversation 解题记录被删除后断开关联，避免下次保存时重新写回
+func (s *Solver) DetachConversation(recordID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conv := range s.conversations {
+		if conv.record != nil && conv.record.ID == recordID {
+			conv.record = nil
+		}
+	}
 }
 
 func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
+	s.CancelReview()
+
 	// 1. 检查 API Key
 	if req.Config.APIKey == "" {
 		if cb.EmitEvent != nil {
@@ -48,67 +132,285 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 		return false
 	}
 
+	s.mu.Lock()
+	conv, err := s.conversation(req.ConversationID)
+	if err != nil {
+		s.mu.Unlock()
+		logger.Printf("[解题] %v", err)
+		if cb.EmitEvent != nil {
+			cb.EmitEvent("solution-error", err.Error())
+		}
+		return false
+	}
+	provider, fastProvider := s.providerFor(req), s.fastProvider
+
 	logger.Println("开始解题流程...")
 
 	// 2. 构建 System Prompt
-	var systemPrompt bytes.Buffer
-	if req.Config.Prompt != "" {
-		systemPrompt.WriteString(req.Config.Prompt)
-	}
-
-	// 如果使用 Markdown 简历，将简历内容追加到 System Prompt
-	if req.Config.UseMarkdownResume && req.Config.ResumeContent != "" {
-		logger.Println("使用 Markdown 简历内容")
-		systemPrompt.WriteString("\n\n# 候选人简历内容如下: \n")
-		systemPrompt.WriteString(req.Config.ResumeContent)
-	}
+	systemPrompt := buildSystemPrompt(req)
 
 	// 3. 构建当前用户消息（包含截图）
-	userParts := []llm.ContentPart{
-		llm.ImagePart(req.ScreenshotBase64),
-	}
-
-	// 如果使用 PDF 简历，将简历附件加入用户消息
-	if !req.Config.UseMarkdownResume && req.ResumeBase64 != "" {
-		userParts = append(userParts,
-			llm.TextPart("\n\n# 候选人简历已作为附件发送，请参考简历内容回答。"),
-			llm.PDFPart(req.ResumeBase64),
-		)
-		logger.Println("已注入简历附件 (PDF)")
-	}
-
-	currentUserMsg := llm.NewMultiPartMessage(llm.RoleUser, userParts)
+	currentUserMsg := buildUserMessage(req)
 
 	// 4. 构建最终发送的消息列表
 	var messagesToSend []llm.Message
 
 	if req.Config.KeepContext {
-		// 保持上下文模式：使用并更新历史记录
-		s.ensureSystemPrompt(systemPrompt.String())
-		messagesToSend = append(messagesToSend, s.chatHistory...)
+		// 保持上下文模式：使用并更新对话历史
+		conv.ensureSystemPrompt(systemPrompt)
+		messagesToSend = conv.snapshot()
+		s.mu.Unlock()
+		// 缩小早期截图，历史超出预算时按策略压缩
+		messagesToSend = s.compactHistory(ctx, req, provider, conv, messagesToSend, currentUserMsg, cb)
 	} else {
 		// 不保持上下文模式：每次都是全新对话
-		messagesToSend = append(messagesToSend, llm.NewSystemMessage(systemPrompt.String()))
+		messagesToSend = append(messagesToSend, llm.NewSystemMessage(systemPrompt))
+		s.mu.Unlock()
 	}
 	messagesToSend = append(messagesToSend, currentUserMsg)
 
-	// 5. 调用 LLM 生成回答
+	// 5. 调用 LLM 生成回答（请求期间不持有锁，其他对话可同时进行）
 	if cb.EmitEvent != nil {
 		cb.EmitEvent("solution-stream-start")
 	}
 
-	response, err := s.llmProvider.GenerateContentStream(ctx, messagesToSend, func(chunk llm.StreamChunk) {
-		if cb.EmitEvent != nil {
-			// 根据 chunk 类型发送不同事件
-			switch chunk.Type {
-			case llm.ChunkThinking:
-				cb.EmitEvent("solution-stream-thinking", chunk.Content)
-			case llm.ChunkContent:
-				cb.EmitEvent("solution-stream-chunk", chunk.Content)
+	// 快慢双模型：快速模型并行作答，主模型照常流式输出
+	var fast *fastRun
+	if req.Config.SpeculativeSolve && fastProvider != nil {
+		fast = startFast(ctx, fastProvider, messagesToSend, req.Config, cb)
+	}
+
+	start := time.Now()
+	response, firstChunk, err := streamAnswer(ctx, provider, messagesToSend, req.Config, cb, fast.onStrongContent)
+
+	// 主模型失败时保留快速模型的回答
+	if err != nil || response.Content == "" {
+		if fastResponse, ok := fast.wait(); ok && ctx.Err() == nil {
+			logger.Println("[解题] 主模型未返回结果，保留快速模型回答")
+			s.commit(conv, systemPrompt, currentUserMsg, history.Turn{
+				User:       currentUserMsg,
+				Model:      req.Config.FastModel,
+				Thinking:   fastResponse.Thinking,
+				Answer:     fastResponse.Content,
+				Usage:      fastResponse.Usage,
+				StartedAt:  start,
+				DurationMs: time.Since(start).Milliseconds(),
+				Routing:    req.Routing,
+			}, !req.Config.KeepContext, cb)
+			if cb.EmitEvent != nil {
+				cb.EmitEvent("solution", fastResponse.Content)
 			}
+			s.publishCodeBlocks(conv, fastResponse.Content, cb)
+			return true
+		}
+	} else {
+		fast.onStrongContent()
+		fast.wait()
+	}
+
+	turn := history.Turn{
+		User:         currentUserMsg,
+		Model:        req.Config.Model,
+		Thinking:     response.Thinking,
+		Answer:       response.Content,
+		Usage:        response.Usage,
+		StartedAt:    start,
+		DurationMs:   time.Since(start).Milliseconds(),
+		FirstChunkMs: firstChunk.Milliseconds(),
+		Routing:      req.Routing,
+	}
+
+	// 6. 处理结果
+	if s.keepInterrupted(ctx, err, conv, systemPrompt, turn, !req.Config.KeepContext, cb) {
+		return false
+	}
+	if !checkResponse(ctx, response, err, cb) {
+		return false
+	}
+
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution", response.Content)
+	}
+	s.publishCodeBlocks(conv, response.Content, cb)
+	s.startReview(ctx, req, currentUserMsg, response.Content, cb)
+
+	// 保持上下文模式：追加到历史；否则以本轮开启新对话（仅用于追问）
+	s.commit(conv, systemPrompt, currentUserMsg, turn, !req.Config.KeepContext, cb)
+
+	return true
+}
+
+// Ask 在对话中追加文字追问（可附带新截图）
+// 无论是否开启 KeepContext，追问都基于对话已有内容进行
+func (s *Solver) Ask(ctx context.Context, req Request, cb Callbacks) bool {
+	s.CancelReview()
+
+	if req.Config.APIKey == "" {
+		if cb.EmitEvent != nil {
+			cb.EmitEvent("require-login")
+		}
+		return false
+	}
+	if strings.TrimSpace(req.Text) == "" && req.ScreenshotBase64 == "" && len(req.Screenshots) == 0 && len(req.Attachments) == 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	conv, err := s.conversation(req.ConversationID)
+	if err != nil {
+		s.mu.Unlock()
+		logger.Printf("[解题] %v", err)
+		if cb.EmitEvent != nil {
+			cb.EmitEvent("solution-error", err.Error())
+		}
+		return false
+	}
+	provider := s.providerFor(req)
+
+	logger.Println("开始追问...")
+
+	systemPrompt := buildSystemPrompt(req)
+	// 简历已随首轮消息发送，追问时不再重复附带
+	if conv.hasUserTurn() {
+		req.ResumeBase64 = ""
+	}
+	conv.ensureSystemPrompt(systemPrompt)
+
+	userMsg := buildUserMessage(req)
+	previous := conv.snapshot()
+	s.mu.Unlock()
+
+	// 缩小早期截图，历史超出预算时按策略压缩
+	messagesToSend := append(s.compactHistory(ctx, req, provider, conv, previous, userMsg, cb), userMsg)
+
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution-stream-start")
+	}
+
+	start := time.Now()
+	response, firstChunk, err := streamAnswer(ctx, provider, messagesToSend, req.Config, cb, nil)
+	turn := history.Turn{
+		User:         userMsg,
+		Model:        req.Config.Model,
+		Thinking:     response.Thinking,
+		Answer:       response.Content,
+		Usage:        response.Usage,
+		StartedAt:    start,
+		DurationMs:   time.Since(start).Milliseconds(),
+		FirstChunkMs: firstChunk.Milliseconds(),
+	}
+	if s.keepInterrupted(ctx, err, conv, systemPrompt, turn, false, cb) {
+		return false
+	}
+	if !checkResponse(ctx, response, err, cb) {
+		return false
+	}
+
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("solution", response.Content)
+	}
+	s.publishCodeBlocks(conv, response.Content, cb)
+	s.startReview(ctx, req, userMsg, response.Content, cb)
+
+	s.commit(conv, systemPrompt, userMsg, turn, false, cb)
+
+	return true
+}
+
+// commit 将本轮问答追加到对话并写入解题记录，newThread 为 true 时以本轮开启新对话
+func (s *Solver) commit(conv *Conversation, systemPrompt string, userMsg llm.Message, turn history.Turn, newThread bool, cb Callbacks) {
+	s.mu.Lock()
+	conv.commitTurn(systemPrompt, userMsg, turn.Answer, newThread)
+	conv.interrupted = turn.Interrupted
+	var record *history.Conversation
+	store := s.history
+	if store != nil {
+		record = conv.recordTurn(systemPrompt, turn, newThread)
+	}
+	s.mu.Unlock()
+
+	s.saveRecord(store, record, cb)
+}
+
+// saveRecord 在 mu 之外保存解题记录副本，写盘期间不阻塞其他对话的读取
+func (s *Solver) saveRecord(store *history.Store, record *history.Conversation, cb Callbacks) {
+	if store == nil || record == nil {
+		return
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	if len(record.Turns) < s.savedTurns[record.ID] {
+		return
+	}
+	if err := store.Save(record); err != nil {
+		logger.Printf("[解题] 保存解题记录失败: %v", err)
+		return
+	}
+	s.savedTurns[record.ID] = len(record.Turns)
+
+	if cb.EmitEvent != nil {
+		cb.EmitEvent("history-updated", record.Summary())
+	}
+}
+
+// streamAnswer 请求主模型并通过 solution-stream-* 事件转发流式输出
+// onContent 在每个正文块到达时调用，可为 nil
+func streamAnswer(ctx context.Context, provider llm.Provider, messages []llm.Message, cfg config.Config, cb Callbacks, onContent func()) (llm.Message, time.Duration, error) {
+	start := time.Now()
+	var firstChunk time.Duration
+	var firstChunkOnce sync.Once
+
+	stream := newStreamEmitter(cfg, cb)
+	// 累积已输出的内容，请求被取消时作为部分回答返回
+	var mu sync.Mutex
+	var content, thinking strings.Builder
+	response, err := provider.GenerateContentStream(ctx, messages, func(chunk llm.StreamChunk) {
+		firstChunkOnce.Do(func() {
+			firstChunk = time.Since(start)
+		})
+		if chunk.Type == llm.ChunkContent && onContent != nil {
+			onContent()
+		}
+		// 根据 chunk 类型发送不同事件
+		mu.Lock()
+		switch chunk.Type {
+		case llm.ChunkThinking:
+			thinking.WriteString(chunk.Content)
+			stream.Append("solution-stream-thinking", chunk.Content)
+		case llm.ChunkContent:
+			content.WriteString(chunk.Content)
+			stream.Append("solution-stream-chunk", chunk.Content)
 		}
+		mu.Unlock()
 	})
+	// 结束或出错时先发送剩余片段，之后才是 solution / solution-error
+	stream.Close()
 
+	if err != nil && response.Content == "" && response.Thinking == "" {
+		mu.Lock()
+		response.Content, response.Thinking = content.String(), thinking.String()
+		mu.Unlock()
+	}
+	return response, firstChunk, err
+}
+
+// newStreamEmitter 按配置的间隔合并流式输出事件
+func newStreamEmitter(cfg config.Config, cb Callbacks) *common.StreamEmitter {
+	interval := time.Duration(cfg.StreamFlushInterval) * time.Millisecond
+	return common.NewStreamEmitter(cb.EmitEvent, interval, 0)
+}
+
+// providerFor 返回本次请求使用的 Provider，调用方需持有锁
+func (s *Solver) providerFor(req Request) llm.Provider {
+	if req.Provider != nil {
+		return req.Provider
+	}
+	return s.llmProvider
+}
+
+// checkResponse 检查请求错误与空回答，失败时发送 solution-error
+func checkResponse(ctx context.Context, response llm.Message, err error, cb Callbacks) bool {
 	if err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
 			logger.Println("当前任务已中断 (用户产生新输入)")
@@ -124,7 +426,6 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 		return false
 	}
 
-	// 6. 处理结果
 	logger.Printf("[解题] 模型返回内容长度: %d", len(response.Content))
 	logger.Printf("[解题] 模型返回内容: %s", response.Content)
 	logger.Printf("[解题] 模型返回思考链长度: %d", len(response.Thinking))
@@ -137,40 +438,86 @@ func (s *Solver) Solve(ctx context.Context, req Request, cb Callbacks) bool {
 		}
 		return false
 	}
+	return true
+}
 
+// publishCodeBlocks 解析回答中的代码块并发送 solution-code-blocks 事件
+func (s *Solver) publishCodeBlocks(conv *Conversation, content string, cb Callbacks) {
+	blocks := codeblock.Extract(content)
+	s.mu.Lock()
+	conv.codeBlocks = blocks
+	s.mu.Unlock()
 	if cb.EmitEvent != nil {
-		cb.EmitEvent("solution", response.Content)
+		cb.EmitEvent("solution-code-blocks", blocks)
 	}
+}
 
-	if req.Config.KeepContext {
-		// 保持上下文模式：保存完整的用户消息和助手回复到历史
-		s.chatHistory = append(s.chatHistory, currentUserMsg)
-		s.chatHistory = append(s.chatHistory, llm.NewAssistantMessage(response.Content))
-	} else {
-		// 不保持上下文模式：清空历史
-		s.chatHistory = []llm.Message{}
+// CodeBlock 返回当前对话最近一次回答中的第 index 个代码块
+func (s *Solver) CodeBlock(index int) (codeblock.Block, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conv, err := s.conversation("")
+	if err != nil || index < 0 || index >= len(conv.codeBlocks) {
+		return codeblock.Block{}, false
 	}
+	return conv.codeBlocks[index], true
+}
 
-	return true
+// LastAnswer 返回当前对话中最近一次回答
+func (s *Solver) LastAnswer() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conv, err := s.conversation("")
+	if err != nil {
+		return ""
+	}
+	return conv.lastAnswer()
 }
 
-// ensureSystemPrompt 确保 chatHistory 的第一条是正确的 System Prompt
-func (s *Solver) ensureSystemPrompt(prompt string) {
-	if len(s.chatHistory) == 0 {
-		s.chatHistory = append(s.chatHistory, llm.NewSystemMessage(prompt))
-		logger.Println("插入 SystemPrompt")
-		return
+// buildSystemPrompt 构建 System Prompt（含 Markdown 简历与语言偏好）
+func buildSystemPrompt(req Request) string {
+	var systemPrompt bytes.Buffer
+	if req.Config.Prompt != "" {
+		systemPrompt.WriteString(req.Config.Prompt)
 	}
 
-	// 检查第一条是否为系统消息
-	if s.chatHistory[0].Role == llm.RoleSystem {
-		if s.chatHistory[0].Content != prompt {
-			s.chatHistory[0] = llm.NewSystemMessage(prompt)
-			logger.Println("替换 SystemPrompt")
+	// 如果使用 Markdown 简历，将简历内容追加到 System Prompt
+	if req.Config.UseMarkdownResume && req.Config.ResumeContent != "" {
+		logger.Println("使用 Markdown 简历内容")
+		systemPrompt.WriteString("\n\n# 候选人简历内容如下: \n")
+		systemPrompt.WriteString(req.Config.ResumeContent)
+	}
+
+	// 回答语言与编程语言偏好
+	systemPrompt.WriteString(prompts.LanguageInstruction(req.Config.AnswerLanguage, req.Config.CodeLanguages))
+	return systemPrompt.String()
+}
+
+// buildUserMessage 构建当前用户消息（截图 + 文件 + 追问文字 + PDF 简历）
+func buildUserMessage(req Request) llm.Message {
+	var userParts []llm.ContentPart
+	if len(req.Screenshots) > 0 {
+		// 多页截图按顺序发送，并提示模型将其视为同一题目
+		userParts = append(userParts, llm.TextPart(fmt.Sprintf("以下 %d 张截图按顺序属于同一内容，请合并阅读后作答。", len(req.Screenshots))))
+		for _, screenshot := range req.Screenshots {
+			userParts = append(userParts, llm.ImagePart(screenshot))
 		}
-	} else {
-		// 第一条不是系统消息，插入到头部
-		s.chatHistory = append([]llm.Message{llm.NewSystemMessage(prompt)}, s.chatHistory...)
-		logger.Println("插入 SystemPrompt 到消息历史头部")
+	} else if req.ScreenshotBase64 != "" {
+		userParts = append(userParts, llm.ImagePart(req.ScreenshotBase64))
 	}
+	userParts = append(userParts, req.Attachments...)
+	if text := strings.TrimSpace(req.Text); text != "" {
+		userParts = append(userParts, llm.TextPart(text))
+	}
+
+	// 如果使用 PDF 简历，将简历附件加入用户消息
+	if !req.Config.UseMarkdownResume && req.ResumeBase64 != "" {
+		userParts = append(userParts,
+			llm.TextPart(history.ResumeNotice),
+			llm.PDFPart(req.ResumeBase64),
+		)
+		logger.Println("已注入简历附件 (PDF)")
+	}
+
+	return llm.NewMultiPartMessage(llm.RoleUser, userParts)
 }
@@ -0,0 +1,104 @@
+package solution
+
+import (
+	"Q-Solver/pkg/common"
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"context"
+	"sync"
+)
+
+// 主模型回答到达后快速回答的展示方式
+const (
+	DisplayReplace   = "replace"
+	DisplayAlongside = "alongside"
+)
+
+// fastRun 快速模型的一次并行作答
+// 与主模型共用任务 context，TaskCoordinator 取消任务时两者一起停止
+type fastRun struct {
+	cancel     context.CancelFunc
+	done       chan struct{}
+	result     llm.Message
+	err        error
+	display    string
+	superseded sync.Once
+	stream     *common.StreamEmitter // 流式片段与其他事件共用，保证发送顺序
+}
+
+// startFast 使用快速模型并行请求同一组消息
+func startFast(ctx context.Context, provider llm.Provider, messages []llm.Message, cfg config.Config, cb Callbacks) *fastRun {
+	fastCtx, cancel := context.WithCancel(ctx)
+
+	display := cfg.SpeculativeDisplay
+	if display == "" {
+		display = DisplayReplace
+	}
+
+	run := &fastRun{
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		display: display,
+		stream:  newStreamEmitter(cfg, cb),
+	}
+
+	logger.Printf("[解题] 快速模型并行作答: %s", cfg.FastModel)
+	run.emitEvent("solution-fast-stream-start", map[string]string{
+		"model":   cfg.FastModel,
+		"display": display,
+	})
+
+	go func() {
+		defer close(run.done)
+		defer run.stream.Close()
+
+		run.result, run.err = provider.GenerateContentStream(fastCtx, messages, func(chunk llm.StreamChunk) {
+			switch chunk.Type {
+			case llm.ChunkThinking:
+				run.stream.Append("solution-fast-stream-thinking", chunk.Content)
+			case llm.ChunkContent:
+				run.stream.Append("solution-fast-stream-chunk", chunk.Content)
+			}
+		})
+
+		if run.err != nil {
+			// 被主模型替换或任务取消时不算错误
+			if fastCtx.Err() == nil {
+				logger.Printf("[解题] 快速模型请求失败: %v", run.err)
+				run.emitEvent("solution-fast-error", run.err.Error())
+			}
+			return
+		}
+		run.emitEvent("solution-fast", run.result.Content)
+	}()
+
+	return run
+}
+
+// onStrongContent 主模型开始输出正文
+// replace 模式下停止快速模型并通知前端用主模型回答替换
+func (r *fastRun) onStrongContent() {
+	if r == nil || r.display != DisplayReplace {
+		return
+	}
+	r.superseded.Do(func() {
+		r.cancel()
+		r.emitEvent("solution-fast-superseded")
+	})
+}
+
+// wait 等待快速模型结束，返回其成功的回答
+func (r *fastRun) wait() (llm.Message, bool) {
+	if r == nil {
+		return llm.Message{}, false
+	}
+	<-r.done
+	r.cancel()
+	return r.result, r.err == nil && r.result.Content != ""
+}
+
+// emitEvent 发送事件，先发送尚未发出的流式片段
+func (r *fastRun) emitEvent(event string, data ...interface{}) {
+	r.stream.Emit(event, data...)
+}
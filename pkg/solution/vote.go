@@ -0,0 +1,223 @@
+package solution
+
+import (
+	"Q-Solver/pkg/history"
+	"Q-Solver/pkg/llm"
+	"Q-Solver/pkg/logger"
+	"Q-Solver/pkg/prompts"
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VoteSample 一次采样的结果
+type VoteSample struct {
+	Index     int    `json:"index"`
+	Answer    string `json:"answer,omitempty"` // 归一化后的最终答案，未能提取时为空
+	Reasoning string `json:"reasoning,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// VoteCount 一个答案获得的票数
+type VoteCount struct {
+	Answer string `json:"answer"`
+	Count  int    `json:"count"`
+}
+
+// VoteResult 多次采样投票的结果（vote-result 事件）
+type VoteResult struct {
+	Answer    string       `json:"answer"`    // 多数答案，没有有效采样时为空
+	Agreement float64      `json:"agreement"` // 多数答案占有效采样的比例
+	Valid     int          `json:"valid"`     // 提取出最终答案的采样数
+	Counts    []VoteCount  `json:"counts"`    // 按票数降序
+	Samples   []VoteSample `json:"samples"`
+	Dissent   []VoteSample `json:"dissent,omitempty"` // 答案与多数不同的采样及其推理
+}
+
+// 采样次数范围（与配置校验一致）
+const (
+	minVoteSamples = 2
+	maxVoteSamples = 15
+)
+
+var (
+	// 标记前只允许同一行的列表符号、加粗等标点（\W 会匹配中文与换行，不能使用）
+	finalAnswerPattern = regexp.MustCompile(`(?im)^[^\p{L}\p{N}\n]*(?:最终答案|final\s+answer)[*\s]*[:：][*\s]*(.+?)\s*$`)
+	choicePattern      = regexp.MustCompile(`^[A-Ha-h](?:\s*[,，、和及&]?\s*[A-Ha-h])*$`)
+)
+
+// Vote 对同一题目并行采样多次，提取每次的最终答案并多数表决
+// 不读取对话历史，表决结果的代表性回答作为一轮写入当前对话
+func (s *Solver) Vote(ctx context.Context, req Request, cb Callbacks) (VoteResult, bool) {
+	s.CancelReview()
+
+	emit := func(event string, data ...interface{}) {
+		if cb.EmitEvent != nil {
+			cb.EmitEvent(event, data...)
+		}
+	}
+	if req.Config.APIKey == "" {
+		emit("require-login")
+		return VoteResult{}, false
+	}
+
+	s.mu.RLock()
+	conv, err := s.conversation(req.ConversationID)
+	provider := s.providerFor(req)
+	s.mu.RUnlock()
+	if err != nil {
+		logger.Printf("[投票] %v", err)
+		emit("solution-error", err.Error())
+		return VoteResult{}, false
+	}
+
+	samples := min(max(req.Config.ConsistencySamples, minVoteSamples), maxVoteSamples)
+	concurrency := min(max(req.Config.ConsistencyConcurrency, 1), samples)
+
+	systemPrompt := buildSystemPrompt(req) + prompts.ConsistencyPrompt
+	userMsg := buildUserMessage(req)
+	messages := []llm.Message{llm.NewSystemMessage(systemPrompt), userMsg}
+
+	logger.Printf("[投票] 采样 %d 次（并发 %d）", samples, concurrency)
+	emit("vote-start", samples)
+
+	// 每次采样都必须真实请求模型，命中响应缓存会让所有采样得到同一个回答
+	sampleCtx := llm.WithoutCache(ctx)
+	start := time.Now()
+	results := make([]VoteSample, samples)
+	responses := make([]llm.Message, samples)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < samples; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = VoteSample{Index: i, Error: ctx.Err().Error()}
+				return
+			}
+
+			sampleStart := time.Now()
+			response, err := provider.GenerateContent(sampleCtx, "", messages)
+			sample := VoteSample{Index: i, LatencyMs: time.Since(sampleStart).Milliseconds()}
+			if err != nil {
+				sample.Error = err.Error()
+			} else {
+				sample.Answer, sample.Reasoning = extractFinalAnswer(response.Content)
+				responses[i] = response
+			}
+			results[i] = sample
+			emit("vote-sample", sample)
+		}(i)
+	}
+	wg.Wait()
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		logger.Println("当前任务已中断 (用户产生新输入)")
+		emit("solution-error", "context canceled")
+		return VoteResult{}, false
+	}
+
+	result := tallyVotes(results)
+	emit("vote-result", result)
+	if result.Answer == "" {
+		emit("solution-error", "所有采样均未能给出可提取的最终答案")
+		return result, false
+	}
+	logger.Printf("[投票] 多数答案: %s (%d/%d)", result.Answer, result.Counts[0].Count, result.Valid)
+
+	// 选择第一份与多数答案一致的回答作为代表展示并写入对话
+	var representative llm.Message
+	for _, sample := range results {
+		if sample.Answer == result.Answer {
+			representative = responses[sample.Index]
+			break
+		}
+	}
+	emit("solution", representative.Content)
+	s.publishCodeBlocks(conv, representative.Content, cb)
+
+	s.commit(conv, systemPrompt, userMsg, history.Turn{
+		User:       userMsg,
+		Model:      req.Config.Model,
+		Thinking:   representative.Thinking,
+		Answer:     representative.Content,
+		Usage:      representative.Usage,
+		StartedAt:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Routing:    req.Routing,
+	}, !req.Config.KeepContext, cb)
+	return result, true
+}
+
+// tallyVotes 统计各答案票数，票数相同时取先出现的答案
+func tallyVotes(samples []VoteSample) VoteResult {
+	result := VoteResult{Samples: samples}
+	for _, sample := range samples {
+		if sample.Answer == "" {
+			continue
+		}
+		result.Valid++
+		idx := slices.IndexFunc(result.Counts, func(c VoteCount) bool { return c.Answer == sample.Answer })
+		if idx < 0 {
+			result.Counts = append(result.Counts, VoteCount{Answer: sample.Answer, Count: 1})
+		} else {
+			result.Counts[idx].Count++
+		}
+	}
+	if result.Valid == 0 {
+		return result
+	}
+
+	slices.SortStableFunc(result.Counts, func(a, b VoteCount) int { return b.Count - a.Count })
+	result.Answer = result.Counts[0].Answer
+	result.Agreement = float64(result.Counts[0].Count) / float64(result.Valid)
+	for _, sample := range samples {
+		if sample.Answer != "" && sample.Answer != result.Answer {
+			result.Dissent = append(result.Dissent, sample)
+		}
+	}
+	return result
+}
+
+// extractFinalAnswer 从回答中提取最后一个“最终答案”（或英文 “Final answer”）行，返回归一化的答案与之前的推理
+func extractFinalAnswer(content string) (string, string) {
+	matches := finalAnswerPattern.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return "", strings.TrimSpace(content)
+	}
+	last := matches[len(matches)-1]
+	answer := normalizeAnswer(content[last[2]:last[3]])
+	return answer, strings.TrimSpace(content[:last[0]])
+}
+
+// normalizeAnswer 统一答案写法：选项字母去重排序，数值去掉千分位并统一格式
+func normalizeAnswer(answer string) string {
+	answer = strings.Trim(strings.TrimSpace(answer), "*`$。，,;；")
+	answer = strings.TrimSpace(answer)
+
+	if choicePattern.MatchString(answer) {
+		var letters []rune
+		for _, r := range strings.ToUpper(answer) {
+			if r >= 'A' && r <= 'H' && !slices.Contains(letters, r) {
+				letters = append(letters, r)
+			}
+		}
+		slices.Sort(letters)
+		return string(letters)
+	}
+
+	if v, err := strconv.ParseFloat(strings.ReplaceAll(answer, ",", ""), 64); err == nil {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strings.ToLower(answer)
+}
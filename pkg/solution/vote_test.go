@@ -0,0 +1,129 @@
+package solution
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"B", "B"},
+		{"b, a", "AB"},
+		{"A和C", "AC"},
+		{"C、A、C", "AC"},
+		{"**D**", "D"},
+		{"1,000", "1000"},
+		{"3.50", "3.5"},
+		{"1e3", "1000"},
+		{"$42$。", "42"},
+		{"Hello World", "hello world"},
+		{"ABCI", "abci"},
+		{"  x + 1  ", "x + 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := normalizeAnswer(tt.in); got != tt.want {
+				t.Errorf("normalizeAnswer(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractFinalAnswer(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantAnswer    string
+		wantReasoning string
+	}{
+		{"chinese", "先分析。\n最终答案：B", "B", "先分析。"},
+		{"english bold", "Reasoning here.\n**Final Answer:** 42", "42", "Reasoning here."},
+		{"bold label", "推理\n**最终答案**：C, A", "AC", "推理"},
+		{"last one wins", "最终答案：A\n再检查一遍\n最终答案: B", "B", "最终答案：A\n再检查一遍"},
+		{"list marker", "分析\n- 最终答案：1,024", "1024", "分析"},
+		{"missing", "  只有推理  ", "", "只有推理"},
+		{"not at line start", "我认为最终答案：A", "", "我认为最终答案：A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, reasoning := extractFinalAnswer(tt.content)
+			if answer != tt.wantAnswer || reasoning != tt.wantReasoning {
+				t.Errorf("extractFinalAnswer() = %q, %q, want %q, %q", answer, reasoning, tt.wantAnswer, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestTallyVotes(t *testing.T) {
+	samples := func(answers ...string) []VoteSample {
+		result := make([]VoteSample, len(answers))
+		for i, a := range answers {
+			result[i] = VoteSample{Index: i, Answer: a}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name          string
+		samples       []VoteSample
+		wantAnswer    string
+		wantAgreement float64
+		wantValid     int
+		wantCounts    []VoteCount
+		wantDissent   []int
+	}{
+		{
+			name:       "no valid samples",
+			samples:    samples("", ""),
+			wantCounts: nil,
+		},
+		{
+			name:          "majority",
+			samples:       samples("A", "B", "A", "", "A"),
+			wantAnswer:    "A",
+			wantAgreement: 0.75,
+			wantValid:     4,
+			wantCounts:    []VoteCount{{"A", 3}, {"B", 1}},
+			wantDissent:   []int{1},
+		},
+		{
+			name:          "tie keeps first seen",
+			samples:       samples("B", "A", "A", "B"),
+			wantAnswer:    "B",
+			wantAgreement: 0.5,
+			wantValid:     4,
+			wantCounts:    []VoteCount{{"B", 2}, {"A", 2}},
+			wantDissent:   []int{1, 2},
+		},
+		{
+			name:          "unanimous",
+			samples:       samples("42", "42"),
+			wantAnswer:    "42",
+			wantAgreement: 1,
+			wantValid:     2,
+			wantCounts:    []VoteCount{{"42", 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tallyVotes(tt.samples)
+			if got.Answer != tt.wantAnswer || got.Agreement != tt.wantAgreement || got.Valid != tt.wantValid {
+				t.Errorf("answer %q agreement %v valid %d, want %q %v %d",
+					got.Answer, got.Agreement, got.Valid, tt.wantAnswer, tt.wantAgreement, tt.wantValid)
+			}
+			if !reflect.DeepEqual(got.Counts, tt.wantCounts) {
+				t.Errorf("Counts = %v, want %v", got.Counts, tt.wantCounts)
+			}
+			var dissent []int
+			for _, s := range got.Dissent {
+				dissent = append(dissent, s.Index)
+			}
+			if !reflect.DeepEqual(dissent, tt.wantDissent) {
+				t.Errorf("Dissent = %v, want %v", dissent, tt.wantDissent)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package telemetry
+
+import (
+	"Q-Solver/pkg/config"
+	"Q-Solver/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// 导出方式
+const (
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+// defaultFileName 本地导出的默认文件名
+const defaultFileName = "telemetry.jsonl"
+
+// metricInterval 指标导出间隔
+const metricInterval = 30 * time.Second
+
+// Service 管理 OpenTelemetry SDK 的生命周期
+type Service struct {
+	mu        sync.Mutex
+	configDir string
+	shutdown  func(context.Context) error
+}
+
+// NewService 创建可观测性服务，并根据配置变更自动重建导出器
+func NewService(cfg config.Config, cm *config.ConfigManager) *Service {
+	s := &Service{
+		configDir: cm.GetConfigDir(),
+	}
+	s.apply(cfg)
+
+	// 自注册配置变更回调
+	cm.Subscribe(func(NewConfig config.Config, oldConfig config.Config) {
+		if NewConfig.TelemetryExporter == oldConfig.TelemetryExporter &&
+			NewConfig.TelemetryEndpoint == oldConfig.TelemetryEndpoint &&
+			NewConfig.TelemetryFile == oldConfig.TelemetryFile {
+			return
+		}
+		s.apply(NewConfig)
+	})
+
+	return s
+}
+
+// Shutdown 刷新并关闭导出器
+func (s *Service) Shutdown(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdownLocked(ctx)
+}
+
+// apply 按配置重建 TracerProvider 和 MeterProvider
+func (s *Service) apply(cfg config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.shutdownLocked(ctx)
+
+	if cfg.TelemetryExporter == "" {
+		return
+	}
+
+	shutdown, err := s.setup(ctx, cfg)
+	if err != nil {
+		logger.Printf("初始化 OpenTelemetry 失败: %v", err)
+		return
+	}
+	s.shutdown = shutdown
+	logger.Printf("OpenTelemetry 已启用 (exporter=%s)", cfg.TelemetryExporter)
+}
+
+// shutdownLocked 关闭当前导出器（调用方需持有锁）
+func (s *Service) shutdownLocked(ctx context.Context) {
+	if s.shutdown == nil {
+		return
+	}
+	if err := s.shutdown(ctx); err != nil {
+		logger.Printf("关闭 OpenTelemetry 失败: %v", err)
+	}
+	s.shutdown = nil
+}
+
+// setup 创建导出器并注册为全局 Provider
+func (s *Service) setup(ctx context.Context, cfg config.Config) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var metricExporter sdkmetric.Exporter
+	var closers []func() error
+
+	switch cfg.TelemetryExporter {
+	case ExporterOTLP:
+		traceOpts, metricOpts := otlpOptions(cfg.TelemetryEndpoint)
+		var err error
+		spanExporter, err = otlptracehttp.New(ctx, traceOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("创建 OTLP trace 导出器失败: %w", err)
+		}
+		metricExporter, err = otlpmetrichttp.New(ctx, metricOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("创建 OTLP metric 导出器失败: %w", err)
+		}
+
+	case ExporterFile:
+		path := cfg.TelemetryFile
+		if path == "" {
+			path = filepath.Join(s.configDir, defaultFileName)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("打开导出文件失败: %w", err)
+		}
+		closers = append(closers, file.Close)
+
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("创建文件 trace 导出器失败: %w", err)
+		}
+		metricExporter, err = stdoutmetric.New(stdoutmetric.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("创建文件 metric 导出器失败: %w", err)
+		}
+		logger.Printf("OpenTelemetry 数据写入: %s", path)
+
+	default:
+		return nil, fmt.Errorf("未知的导出方式: %s", cfg.TelemetryExporter)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", "Q-Solver"),
+	)
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(metricInterval))),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	return func(ctx context.Context) error {
+		errs := []error{
+			tracerProvider.Shutdown(ctx),
+			meterProvider.Shutdown(ctx),
+		}
+		for _, closeFn := range closers {
+			errs = append(errs, closeFn())
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// otlpOptions 解析 OTLP/HTTP 地址，支持 "host:port" 或完整 URL
+func otlpOptions(endpoint string) ([]otlptracehttp.Option, []otlpmetrichttp.Option) {
+	if endpoint == "" {
+		// 留空时使用 OTEL_EXPORTER_OTLP_* 环境变量或默认 localhost:4318
+		return nil, nil
+	}
+
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return []otlptracehttp.Option{otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/") + "/v1/traces")},
+			[]otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/") + "/v1/metrics")}
+	}
+
+	return []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure()},
+		[]otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint), otlpmetrichttp.WithInsecure()}
+}